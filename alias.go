@@ -0,0 +1,77 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// SetAlias sets a service of type To with name toName, that is an alias of the service of type From with name fromName.
+//
+// Both keys return the same instance: the alias [Builder] gets the aliased service, and doesn't return a [Close] function.
+// So the instance is closed only once, by the aliased service.
+// The aliased service is the only dependency of the alias.
+//
+// From must be assignable to To, e.g. a concrete type and an interface that it implements.
+func SetAlias[From, To any](ctn *Container, fromName, toName string, opts ...ServiceOption) error {
+	fromTyp := reflect.TypeFor[From]()
+	toTyp := reflect.TypeFor[To]()
+	if !fromTyp.AssignableTo(toTyp) {
+		return ctn.wrapServiceError(fmt.Errorf("alias: %s is not assignable to %s", fromTyp, toTyp), newKey[To](toName))
+	}
+	return Set(ctn, toName, newAliasBuilder[From, To](fromName), opts...)
+}
+
+// MustSetAlias calls [SetAlias] and panics if there is an error.
+func MustSetAlias[From, To any](ctn *Container, fromName, toName string, opts ...ServiceOption) {
+	err := SetAlias[From, To](ctn, fromName, toName, opts...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newAliasBuilder[From, To any](fromName string) Builder[To] {
+	return func(ctx context.Context, ctn *Container) (to To, cl Close, err error) {
+		from, err := Get[From](ctx, ctn, fromName)
+		if err != nil {
+			return to, nil, err
+		}
+		to, _ = any(from).(To) // It is the zero value if from is a nil interface.
+		return to, nil, nil
+	}
+}
+
+// SetAs sets a service of type Iface, that is built by a [Builder] of type Impl.
+//
+// Impl must be assignable to Iface, e.g. a concrete type and an interface that it implements.
+// [Get] with Iface returns the Impl value in the interface.
+//
+// It doesn't set a service of type Impl, so both can be set with different [Builder]s, or with [SetAlias] in order to share the instance.
+// The [Close] function returned by the [Builder] is used.
+func SetAs[Iface, Impl any](ctn *Container, name string, b Builder[Impl], opts ...ServiceOption) error {
+	implTyp := reflect.TypeFor[Impl]()
+	ifaceTyp := reflect.TypeFor[Iface]()
+	if !implTyp.AssignableTo(ifaceTyp) {
+		return ctn.wrapServiceError(fmt.Errorf("%s is not assignable to %s", implTyp, ifaceTyp), newKey[Iface](name))
+	}
+	return Set(ctn, name, newAsBuilder[Iface](b), opts...)
+}
+
+// MustSetAs calls [SetAs] and panics if there is an error.
+func MustSetAs[Iface, Impl any](ctn *Container, name string, b Builder[Impl], opts ...ServiceOption) {
+	err := SetAs[Iface](ctn, name, b, opts...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newAsBuilder[Iface, Impl any](b Builder[Impl]) Builder[Iface] {
+	return func(ctx context.Context, ctn *Container) (iface Iface, cl Close, err error) {
+		impl, cl, err := b(ctx, ctn)
+		if err != nil {
+			return iface, cl, err
+		}
+		iface, _ = any(impl).(Iface) // It is the zero value if impl is a nil interface.
+		return iface, cl, nil
+	}
+}
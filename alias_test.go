@@ -0,0 +1,135 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+type testAliasImpl struct{}
+
+func (testAliasImpl) String() string {
+	return "impl"
+}
+
+func TestSetAlias(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closeCount := 0
+	MustSetPtr(ctn, "impl", func(ctx context.Context, ctn *Container) (*testAliasImpl, Close, error) {
+		return &testAliasImpl{}, func(ctx context.Context) error {
+			closeCount++
+			return nil
+		}, nil
+	})
+	err := SetAlias[*testAliasImpl, fmt.Stringer](ctn, "impl", "alias")
+	assert.NoError(t, err)
+	MustSetAlias[*testAliasImpl, *testAliasImpl](ctn, "impl", "other")
+	impl := MustGet[*testAliasImpl](ctx, ctn, "impl")
+	s := MustGet[fmt.Stringer](ctx, ctn, "alias")
+	assert.Equal(t, s, fmt.Stringer(impl))
+	other := MustGet[*testAliasImpl](ctx, ctn, "other")
+	assert.Equal(t, other, impl)
+	dep, err := GetDependency[fmt.Stringer](ctx, ctn, "alias")
+	assert.NoError(t, err)
+	assert.SliceLen(t, dep.Dependencies, 1)
+	assert.Equal(t, dep.Dependencies[0].Name, "impl")
+	err = ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closeCount, 1)
+}
+
+func TestSetAliasErrorNotAssignable(t *testing.T) {
+	ctn := new(Container)
+	err := SetAlias[int, string](ctn, "", "")
+	var serviceErr *ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+	assert.ErrorEqual(t, err, "service string: alias: int is not assignable to string")
+	assert.Panics(t, func() {
+		MustSetAlias[int, string](ctn, "", "")
+	})
+}
+
+func TestSetAliasErrorNotAssignableErrorWrapper(t *testing.T) {
+	ctn := NewContainer(WithErrorWrapper(func(err error, key Key) error {
+		return &testWrappedError{
+			err: err,
+			key: key,
+		}
+	}))
+	err := SetAlias[int, string](ctn, "", "")
+	var wrappedErr *testWrappedError
+	assert.ErrorAs(t, err, &wrappedErr)
+	assert.ErrorEqual(t, err, "wrapped string: alias: int is not assignable to string")
+}
+
+func TestSetAliasErrorNotSet(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetAlias[*testAliasImpl, fmt.Stringer](ctn, "impl", "alias")
+	_, err := Get[fmt.Stringer](ctx, ctn, "alias")
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.ErrorEqual(t, err, "service fmt.Stringer(alias): service *github.com/pierrre/di.testAliasImpl(impl): not set")
+}
+
+func TestSetAs(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closed := false
+	err := SetAs[fmt.Stringer](ctn, "", func(ctx context.Context, ctn *Container) (*testAliasImpl, Close, error) {
+		return &testAliasImpl{}, func(ctx context.Context) error {
+			closed = true
+			return nil
+		}, nil
+	})
+	assert.NoError(t, err)
+	s := MustGet[fmt.Stringer](ctx, ctn, "")
+	assert.Equal(t, s.String(), "impl")
+	_, ok := s.(*testAliasImpl)
+	assert.True(t, ok)
+	_, err = Get[*testAliasImpl](ctx, ctn, "")
+	assert.ErrorIs(t, err, ErrNotSet)
+	err = ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.True(t, closed)
+}
+
+func TestSetAsErrorNotAssignable(t *testing.T) {
+	ctn := new(Container)
+	b := func(ctx context.Context, ctn *Container) (int, Close, error) {
+		return 0, nil, nil
+	}
+	err := SetAs[fmt.Stringer](ctn, "", b)
+	assert.ErrorEqual(t, err, "service fmt.Stringer: int is not assignable to fmt.Stringer")
+	assert.Panics(t, func() {
+		MustSetAs[fmt.Stringer](ctn, "", b)
+	})
+}
+
+func TestSetAsErrorNotAssignableErrorWrapper(t *testing.T) {
+	ctn := NewContainer(WithErrorWrapper(func(err error, key Key) error {
+		return &testWrappedError{
+			err: err,
+			key: key,
+		}
+	}))
+	err := SetAs[fmt.Stringer](ctn, "", func(ctx context.Context, ctn *Container) (int, Close, error) {
+		return 0, nil, nil
+	})
+	var wrappedErr *testWrappedError
+	assert.ErrorAs(t, err, &wrappedErr)
+	assert.ErrorEqual(t, err, "wrapped fmt.Stringer: int is not assignable to fmt.Stringer")
+}
+
+func TestSetAsErrorBuilder(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetAs[fmt.Stringer](ctn, "", func(ctx context.Context, ctn *Container) (*testAliasImpl, Close, error) {
+		return nil, nil, errors.New("error")
+	})
+	_, err := Get[fmt.Stringer](ctx, ctn, "")
+	assert.ErrorEqual(t, err, "service fmt.Stringer: error")
+}
@@ -0,0 +1,51 @@
+package di
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pierrre/go-libs/reflectutil"
+)
+
+// SetByType sets a service to a [Container], with a type known at runtime.
+//
+// It is a lower level version of [Set], for dynamic wiring.
+// The caller is responsible for returning a service of the given type from the builder.
+// Otherwise, [Get] returns a [TypeMismatchError].
+func SetByType(ctn *Container, typ reflect.Type, name string, b func(ctx context.Context, ctn *Container) (any, Close, error), opts ...ServiceOption) error {
+	key := newKeyByType(typ, name)
+	return ctn.set(key, typ, b, opts)
+}
+
+// MustSetByType calls [SetByType] and panics if there is an error.
+func MustSetByType(ctn *Container, typ reflect.Type, name string, b func(ctx context.Context, ctn *Container) (any, Close, error), opts ...ServiceOption) {
+	err := SetByType(ctn, typ, name, b, opts...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GetByType returns a service from a [Resolver], with a type known at runtime.
+//
+// It is a lower level version of [Get], for dynamic wiring.
+// The service is not checked against the type: the caller is responsible for the type assertion.
+func GetByType(ctx context.Context, ctn Resolver, typ reflect.Type, name string) (any, error) {
+	key := newKeyByType(typ, name)
+	return ctn.container().get(ctx, key)
+}
+
+// MustGetByType calls [GetByType] and panics if there is an error.
+func MustGetByType(ctx context.Context, ctn Resolver, typ reflect.Type, name string) any {
+	s, err := GetByType(ctx, ctn, typ, name)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func newKeyByType(typ reflect.Type, name string) Key {
+	return Key{
+		Type: reflectutil.TypeFullName(typ),
+		Name: name,
+	}
+}
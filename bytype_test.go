@@ -0,0 +1,67 @@
+package di
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestSetByType(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	typ := reflect.TypeFor[string]()
+	MustSetByType(ctn, typ, "", func(ctx context.Context, ctn *Container) (any, Close, error) {
+		return "test", nil, nil
+	})
+	s := MustGet[string](ctx, ctn, "")
+	assert.Equal(t, s, "test")
+	v := MustGetByType(ctx, ctn, typ, "")
+	assert.Equal(t, v, any("test"))
+}
+
+func TestSetByTypeErrorAlreadySet(t *testing.T) {
+	ctn := new(Container)
+	MustSetValue(ctn, "", "test")
+	err := SetByType(ctn, reflect.TypeFor[string](), "", func(ctx context.Context, ctn *Container) (any, Close, error) {
+		return "test", nil, nil
+	})
+	assert.ErrorIs(t, err, ErrAlreadySet)
+}
+
+func TestSetByTypePanic(t *testing.T) {
+	ctn := new(Container)
+	MustSetValue(ctn, "", "test")
+	assert.Panics(t, func() {
+		MustSetByType(ctn, reflect.TypeFor[string](), "", func(ctx context.Context, ctn *Container) (any, Close, error) {
+			return "test", nil, nil
+		})
+	})
+}
+
+func TestSetByTypeErrorTypeMismatch(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetByType(ctn, reflect.TypeFor[string](), "", func(ctx context.Context, ctn *Container) (any, Close, error) {
+		return 1, nil, nil
+	})
+	_, err := Get[string](ctx, ctn, "")
+	var tmErr *TypeMismatchError
+	assert.ErrorAs(t, err, &tmErr)
+}
+
+func TestGetByTypeErrorNotSet(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	_, err := GetByType(ctx, ctn, reflect.TypeFor[string](), "")
+	assert.ErrorIs(t, err, ErrNotSet)
+}
+
+func TestMustGetByTypePanic(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	assert.Panics(t, func() {
+		MustGetByType(ctx, ctn, reflect.TypeFor[string](), "")
+	})
+}
@@ -0,0 +1,118 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pierrre/go-libs/goroutine"
+)
+
+// CloseParallel closes all the services of the [Container] concurrently, with at most maxWorkers services closed at the same time.
+//
+// A service is closed only after all the services that depend on it are closed.
+// Independent services are closed concurrently.
+// If the dependency tracking is disabled (see [WithoutDependencyTracking]), the dependencies are unknown,
+// so the services are closed one at a time, in the reverse initialization order, as in [Container.Close].
+//
+// If the context is canceled, the remaining services are not closed, and the context error is returned.
+//
+// See [Container.Close].
+func (c *Container) CloseParallel(ctx context.Context, maxWorkers int) error {
+	if isClosingContext(ctx) {
+		return ErrCloseReentrant
+	}
+	nodes, err := c.newCloseParallelNodes(ctx, c.getServiceWrappersCloseOrder())
+	if err != nil {
+		return err
+	}
+	cp := &closeParallel{
+		ctn: c,
+		sem: make(chan struct{}, max(maxWorkers, 1)),
+	}
+	wg := new(sync.WaitGroup)
+	for _, n := range nodes {
+		goroutine.WaitGroup(ctx, wg, func(ctx context.Context) {
+			cp.close(ctx, n)
+		})
+	}
+	wg.Wait()
+	if cp.canceled.Load() {
+		cp.errs = append(cp.errs, ctx.Err())
+	}
+	return errors.Join(cp.errs...)
+}
+
+type closeParallelNode struct {
+	sw         *serviceWrapper
+	done       chan struct{}
+	dependents []*closeParallelNode
+}
+
+func (c *Container) newCloseParallelNodes(ctx context.Context, sws []*serviceWrapper) ([]*closeParallelNode, error) {
+	nodes := make([]*closeParallelNode, len(sws))
+	nodesByKey := make(map[Key]*closeParallelNode, len(sws))
+	for i, sw := range sws {
+		n := &closeParallelNode{
+			sw:   sw,
+			done: make(chan struct{}),
+		}
+		nodes[i] = n
+		nodesByKey[sw.key] = n
+	}
+	if c.config.dependencyTrackingDisabled {
+		// Each service waits for the service initialized after it.
+		for i := 1; i < len(nodes); i++ {
+			nodes[i].dependents = []*closeParallelNode{nodes[i-1]}
+		}
+		return nodes, nil
+	}
+	for _, n := range nodes {
+		keys, err := n.sw.getDependencyKeys(ctx)
+		if err != nil {
+			return nil, c.wrapServiceError(err, n.sw.key)
+		}
+		for _, key := range keys {
+			dn, ok := nodesByKey[key]
+			if ok {
+				dn.dependents = append(dn.dependents, n)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+type closeParallel struct {
+	ctn      *Container
+	sem      chan struct{}
+	canceled atomic.Bool
+	mu       sync.Mutex
+	errs     []error
+}
+
+func (cp *closeParallel) close(ctx context.Context, n *closeParallelNode) {
+	defer close(n.done)
+	for _, dn := range n.dependents {
+		<-dn.done
+	}
+	select {
+	case cp.sem <- struct{}{}:
+	case <-ctx.Done():
+		cp.canceled.Store(true)
+		return
+	}
+	defer func() {
+		<-cp.sem
+	}()
+	if ctx.Err() != nil {
+		cp.canceled.Store(true)
+		return
+	}
+	err := n.sw.close(ctx, cp.ctn)
+	if err != nil {
+		cp.mu.Lock()
+		defer cp.mu.Unlock()
+		cp.errs = append(cp.errs, cp.ctn.wrapServiceError(err, n.sw.key))
+	}
+}
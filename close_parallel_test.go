@@ -0,0 +1,110 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestContainerCloseParallel(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var mu sync.Mutex
+	closed := make(map[string]bool)
+	newBuilder := func(name string, deps ...string) Builder[string] {
+		return func(ctx context.Context, ctn *Container) (string, Close, error) {
+			for _, dep := range deps {
+				MustGet[string](ctx, ctn, dep)
+			}
+			return "", func(ctx context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, dep := range deps {
+					if closed[dep] {
+						return errors.New("dependency closed before dependent")
+					}
+				}
+				closed[name] = true
+				return nil
+			}, nil
+		}
+	}
+	MustSet(ctn, "a", newBuilder("a"))
+	MustSet(ctn, "b", newBuilder("b", "a"))
+	MustSet(ctn, "c", newBuilder("c", "a", "b"))
+	MustSet(ctn, "d", newBuilder("d"))
+	MustSet(ctn, "e", newBuilder("e", "d"))
+	MustGet[string](ctx, ctn, "c")
+	MustGet[string](ctx, ctn, "e")
+	err := ctn.CloseParallel(ctx, 4)
+	assert.NoError(t, err)
+	assert.MapLen(t, closed, 5)
+}
+
+func TestContainerCloseParallelWithoutDependencyTracking(t *testing.T) {
+	ctx := context.Background()
+	ctn := NewContainer(WithoutDependencyTracking())
+	var mu sync.Mutex
+	var closed []string
+	newBuilder := func(name string, deps ...string) Builder[string] {
+		return func(ctx context.Context, ctn *Container) (string, Close, error) {
+			for _, dep := range deps {
+				MustGet[string](ctx, ctn, dep)
+			}
+			return "", func(ctx context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				closed = append(closed, name)
+				return nil
+			}, nil
+		}
+	}
+	MustSet(ctn, "a", newBuilder("a"))
+	MustSet(ctn, "b", newBuilder("b", "a"))
+	MustSet(ctn, "c", newBuilder("c", "b"))
+	MustSet(ctn, "d", newBuilder("d"))
+	MustGet[string](ctx, ctn, "c")
+	MustGet[string](ctx, ctn, "d")
+	err := ctn.CloseParallel(ctx, 4)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, closed, []string{"d", "c", "b", "a"})
+}
+
+func TestContainerCloseParallelError(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	for _, name := range []string{"a", "b"} {
+		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "", func(ctx context.Context) error {
+				return errors.New("error")
+			}, nil
+		})
+		MustGet[string](ctx, ctn, name)
+	}
+	err := ctn.CloseParallel(ctx, 2)
+	var serviceErr *ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+	assert.ErrorContains(t, err, "service string(a): error")
+	assert.ErrorContains(t, err, "service string(b): error")
+}
+
+func TestContainerCloseParallelContextCanceled(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closeCalled := false
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			closeCalled = true
+			return nil
+		}, nil
+	})
+	MustGet[string](ctx, ctn, "")
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	err := ctn.CloseParallel(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, closeCalled)
+}
@@ -0,0 +1,99 @@
+package di
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotClosed is returned by [Container.AssertAllClosed] when a service is initialized but not closed.
+var ErrNotClosed = errors.New("not closed")
+
+// WithCloseTracking returns a [ContainerOption] that enables the tracking of the initializations and closes of the services.
+//
+// It allows to call [Container.AssertAllClosed].
+// It is intended to be used for debugging and in tests.
+func WithCloseTracking() ContainerOption {
+	return func(cfg *containerConfig) {
+		cfg.closeTracking = true
+	}
+}
+
+// AssertAllClosed checks that all the services initialized during the lifetime of the [Container] were closed.
+//
+// It returns an [ErrNotClosed] error for each service that was initialized and not closed after that.
+// It is intended to be called at the end of a test, after [Container.Close].
+//
+// It requires [WithCloseTracking], otherwise it returns [ErrCloseTrackingDisabled].
+func (c *Container) AssertAllClosed() error {
+	if !c.config.closeTracking {
+		return ErrCloseTrackingDisabled
+	}
+	var errs []error
+	for _, key := range c.closeCounts.getNotClosed() {
+		errs = append(errs, c.wrapServiceError(ErrNotClosed, key))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Container) trackInit(key Key) {
+	if c.config.closeTracking {
+		c.closeCounts.init(key)
+	}
+}
+
+func (c *Container) trackClose(key Key) {
+	if c.config.closeTracking {
+		c.closeCounts.close(key)
+	}
+}
+
+// closeCounts counts the initializations and closes of the services.
+type closeCounts struct {
+	mu sync.Mutex
+	m  map[Key]*closeCount
+}
+
+type closeCount struct {
+	initialized int
+	closed      int
+}
+
+func (cc *closeCounts) get(key Key) *closeCount {
+	if cc.m == nil {
+		cc.m = make(map[Key]*closeCount)
+	}
+	c, ok := cc.m[key]
+	if !ok {
+		c = new(closeCount)
+		cc.m[key] = c
+	}
+	return c
+}
+
+func (cc *closeCounts) init(key Key) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.get(key).initialized++
+}
+
+// close marks all the initializations of the service as closed.
+// A factory service is initialized for each build, and all the instances are closed at once.
+func (cc *closeCounts) close(key Key) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	c := cc.get(key)
+	c.closed = c.initialized
+}
+
+func (cc *closeCounts) getNotClosed() []Key {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	var keys []Key
+	for key, c := range cc.m {
+		if c.closed < c.initialized {
+			keys = append(keys, key)
+		}
+	}
+	sortKeys(keys)
+	return keys
+}
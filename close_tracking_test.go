@@ -0,0 +1,42 @@
+package di
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestContainerAssertAllClosed(t *testing.T) {
+	ctx := context.Background()
+	ctn := NewContainer(WithCloseTracking())
+	MustSetValue(ctn, "a", "")
+	MustSetValue(ctn, "b", "")
+	MustSetValue(ctn, "c", "")
+	MustSetFactory(ctn, "", func(ctx context.Context, ctn *Container) (int, Close, error) {
+		return 0, nil, nil
+	})
+	assert.NoError(t, ctn.AssertAllClosed())
+	MustGet[string](ctx, ctn, "a")
+	MustGet[string](ctx, ctn, "b")
+	MustGet[int](ctx, ctn, "")
+	MustGet[int](ctx, ctn, "")
+	err := ctn.AssertAllClosed()
+	assert.ErrorIs(t, err, ErrNotClosed)
+	assert.ErrorEqual(t, err, "service int: not closed\nservice string(a): not closed\nservice string(b): not closed")
+	err = ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, ctn.AssertAllClosed())
+	MustGet[string](ctx, ctn, "a")
+	err = ctn.AssertAllClosed()
+	assert.ErrorEqual(t, err, "service string(a): not closed")
+	err = ctn.CloseParallel(ctx, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, ctn.AssertAllClosed())
+}
+
+func TestContainerAssertAllClosedErrorNotEnabled(t *testing.T) {
+	ctn := new(Container)
+	err := ctn.AssertAllClosed()
+	assert.ErrorIs(t, err, ErrCloseTrackingDisabled)
+}
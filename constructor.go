@@ -0,0 +1,123 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/pierrre/go-libs/reflectutil"
+)
+
+// SetConstructor sets a service to a [Container], that is built by a constructor function.
+//
+// The constructor is a function that returns the service, and optionally a [Close] function and an error:
+//   - func(A, B) S
+//   - func(A, B) (S, error)
+//   - func(A, B) (S, Close, error)
+//
+// The first parameter can be a [context.Context].
+// The other parameters are services, that are resolved with [Get], with an empty name.
+// The type of the service is the type of the first result.
+//
+// If the signature is not supported, it returns an error.
+func SetConstructor(ctn *Container, name string, ctor any, opts ...ServiceOption) error {
+	c, err := newConstructor(ctor)
+	if err != nil {
+		return err
+	}
+	key := Key{
+		Type: reflectutil.TypeFullName(c.serviceType),
+		Name: name,
+	}
+	return ctn.set(key, c.serviceType, c.build, opts)
+}
+
+// MustSetConstructor calls [SetConstructor] and panics if there is an error.
+func MustSetConstructor(ctn *Container, name string, ctor any, opts ...ServiceOption) {
+	err := SetConstructor(ctn, name, ctor, opts...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+var (
+	contextType = reflect.TypeFor[context.Context]()
+	closeType   = reflect.TypeFor[Close]()
+	errorType   = reflect.TypeFor[error]()
+)
+
+type constructor struct {
+	fn          reflect.Value
+	serviceType reflect.Type
+	hasContext  bool
+	params      []reflect.Type
+	hasClose    bool
+	hasError    bool
+}
+
+func newConstructor(ctor any) (*constructor, error) {
+	fn := reflect.ValueOf(ctor)
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return nil, fmt.Errorf("constructor: %T is not a function", ctor)
+	}
+	typ := fn.Type()
+	if typ.IsVariadic() {
+		return nil, fmt.Errorf("constructor: unsupported signature %s: variadic", typ)
+	}
+	c := &constructor{
+		fn: fn,
+	}
+	switch {
+	case typ.NumOut() == 1:
+	case typ.NumOut() == 2 && typ.Out(1) == errorType:
+		c.hasError = true
+	case typ.NumOut() == 3 && typ.Out(1) == closeType && typ.Out(2) == errorType:
+		c.hasClose = true
+		c.hasError = true
+	default:
+		return nil, fmt.Errorf("constructor: unsupported signature %s: the results must be (S), (S, error) or (S, di.Close, error)", typ)
+	}
+	c.serviceType = typ.Out(0)
+	for i := range typ.NumIn() {
+		in := typ.In(i)
+		if i == 0 && in == contextType {
+			c.hasContext = true
+			continue
+		}
+		c.params = append(c.params, in)
+	}
+	return c, nil
+}
+
+func (c *constructor) build(ctx context.Context, ctn *Container) (any, Close, error) {
+	args := make([]reflect.Value, 0, len(c.params)+1)
+	if c.hasContext {
+		args = append(args, reflect.ValueOf(&ctx).Elem())
+	}
+	for _, typ := range c.params {
+		key := Key{
+			Type: reflectutil.TypeFullName(typ),
+		}
+		v, err := ctn.get(ctx, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		rv := reflect.New(typ).Elem()
+		if v != nil {
+			rv.Set(reflect.ValueOf(v))
+		}
+		args = append(args, rv)
+	}
+	out := c.fn.Call(args)
+	var cl Close
+	if c.hasClose {
+		cl, _ = out[1].Interface().(Close)
+	}
+	if c.hasError {
+		err, _ := out[len(out)-1].Interface().(error)
+		if err != nil {
+			return nil, cl, err
+		}
+	}
+	return out[0].Interface(), cl, nil
+}
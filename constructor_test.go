@@ -0,0 +1,110 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+type testConstructorService struct {
+	s  string
+	i  int
+	st fmt.Stringer
+}
+
+func TestSetConstructor(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "", "a")
+	MustSetValue(ctn, "", 1)
+	MustSetValue[fmt.Stringer](ctn, "", &testAliasImpl{})
+	err := SetConstructor(ctn, "", func(s string, i int, st fmt.Stringer) *testConstructorService {
+		return &testConstructorService{s: s, i: i, st: st}
+	})
+	assert.NoError(t, err)
+	s := MustGet[*testConstructorService](ctx, ctn, "")
+	assert.Equal(t, s.s, "a")
+	assert.Equal(t, s.i, 1)
+	assert.Equal(t, s.st.String(), "impl")
+	dep, err := GetDependency[*testConstructorService](ctx, ctn, "")
+	assert.NoError(t, err)
+	assert.SliceLen(t, dep.Dependencies, 3)
+}
+
+func TestSetConstructorContextClose(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "", 1)
+	closed := false
+	MustSetConstructor(ctn, "test", func(ctx context.Context, i int) (string, Close, error) {
+		assert.NotZero(t, ctx)
+		return fmt.Sprint(i), func(ctx context.Context) error {
+			closed = true
+			return nil
+		}, nil
+	})
+	s := MustGet[string](ctx, ctn, "test")
+	assert.Equal(t, s, "1")
+	err := ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.True(t, closed)
+}
+
+func TestSetConstructorError(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetConstructor(ctn, "", func() (string, error) {
+		return "", errors.New("error")
+	})
+	_, err := Get[string](ctx, ctn, "")
+	assert.ErrorEqual(t, err, "service string: error")
+}
+
+func TestSetConstructorErrorDependency(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetConstructor(ctn, "", func(i int) string {
+		return ""
+	})
+	_, err := Get[string](ctx, ctn, "")
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.ErrorEqual(t, err, "service string: service int: not set")
+}
+
+func TestSetConstructorErrorSignature(t *testing.T) {
+	ctn := new(Container)
+	for _, tc := range []struct {
+		ctor     any
+		expected string
+	}{
+		{
+			ctor:     "test",
+			expected: "constructor: string is not a function",
+		},
+		{
+			ctor:     (func() string)(nil),
+			expected: "constructor: func() string is not a function",
+		},
+		{
+			ctor:     func(...int) string { return "" },
+			expected: "constructor: unsupported signature func(...int) string: variadic",
+		},
+		{
+			ctor:     func() {},
+			expected: "constructor: unsupported signature func(): the results must be (S), (S, error) or (S, di.Close, error)",
+		},
+		{
+			ctor:     func() (string, int) { return "", 0 },
+			expected: "constructor: unsupported signature func() (string, int): the results must be (S), (S, error) or (S, di.Close, error)",
+		},
+	} {
+		err := SetConstructor(ctn, "", tc.ctor)
+		assert.ErrorEqual(t, err, tc.expected)
+	}
+	assert.Panics(t, func() {
+		MustSetConstructor(ctn, "", "test")
+	})
+}
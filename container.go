@@ -3,72 +3,541 @@ package di
 import (
 	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/pierrre/go-libs/goroutine"
 	"github.com/pierrre/go-libs/reflectutil"
 )
 
 // Container contains services.
+//
+// The zero value is ready to use.
+// [NewContainer] allows to create a [Container] with options.
 type Container struct {
-	services serviceWrapperMap
+	config      containerConfig
+	parent      *Container
+	services    serviceWrapperMap
+	sequence    atomic.Int64
+	onSLOBreach atomic.Pointer[SLOBreachHandler]
+	background  sync.WaitGroup
+	closeCounts closeCounts
+	events      eventHandlers
+	middlewares builderMiddlewares
+	drain       drainState
 }
 
-func (c *Container) set(key Key, typ reflect.Type, b builder) (err error) {
-	defer wrapReturnServiceError(&err, key)
-	sw := newServiceWrapper(key, typ, b)
-	return c.services.set(key, sw)
+func (c *Container) set(key Key, typ reflect.Type, b builder, opts []ServiceOption) error {
+	sw := newServiceWrapper(key, typ, b, opts)
+	return c.setServiceWrapper(sw)
 }
 
-func (c *Container) get(ctx context.Context, key Key) (v any, err error) {
-	defer wrapReturnServiceError(&err, key)
+func (c *Container) setServiceWrapper(sw *serviceWrapper) (err error) {
+	defer c.wrapReturnServiceError(&err, sw.key)
+	if c.drain.closed.Load() {
+		return ErrClosed
+	}
+	err = c.services.set(sw.key, sw)
+	if err != nil {
+		return err
+	}
+	c.logRegistered(sw.key)
+	return nil
+}
+
+// setServiceWrappers sets all the [serviceWrapper]s, or none of them if one is already set.
+func (c *Container) setServiceWrappers(sws []*serviceWrapper) error {
+	if c.drain.closed.Load() {
+		return ErrClosed
+	}
+	i, err := c.services.setAll(sws)
+	if err != nil {
+		return c.wrapServiceError(err, sws[i].key)
+	}
+	for _, sw := range sws {
+		c.logRegistered(sw.key)
+	}
+	return nil
+}
+
+// setIfAbsent sets the [serviceWrapper] if the key is not set, and returns true if it was set.
+func (c *Container) setIfAbsent(sw *serviceWrapper) (ok bool, err error) {
+	defer c.wrapReturnServiceError(&err, sw.key)
+	if c.drain.closed.Load() {
+		return false, ErrClosed
+	}
+	ok = c.services.setIfAbsent(sw.key, sw)
+	if ok {
+		c.logRegistered(sw.key)
+	}
+	return ok, nil
+}
+
+func (c *Container) replace(ctx context.Context, key Key, typ reflect.Type, b builder, opts []ServiceOption) (err error) {
+	defer c.wrapReturnServiceError(&err, key)
+	if c.drain.closed.Load() {
+		return ErrClosed
+	}
+	sw := newServiceWrapper(key, typ, b, opts)
+	old, err := c.services.swap(key, sw)
+	if err != nil {
+		return err
+	}
+	// The previous serviceWrapper is marked as replaced, so a concurrent call that got it before the swap can't build it again after it is closed.
+	return old.close(ctx, c)
+}
+
+func (c *Container) closeService(ctx context.Context, key Key) (err error) {
+	defer c.wrapReturnServiceError(&err, key)
 	sw, err := c.services.get(key)
+	if err != nil {
+		return err
+	}
+	return sw.close(ctx, c)
+}
+
+func (c *Container) get(ctx context.Context, key Key) (v any, err error) {
+	defer c.wrapReturnServiceError(&err, key)
+	err = c.drain.check(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return sw.get(ctx, c)
+	for {
+		sw, ctn, err := c.getServiceWrapper(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err = sw.get(ctx, ctn)
+		if !errors.Is(err, errReplaced) {
+			return v, err
+		}
+	}
+}
+
+func (c *Container) getWithClose(ctx context.Context, key Key) (v any, cl Close, err error) {
+	defer c.wrapReturnServiceError(&err, key)
+	err = c.drain.check(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for {
+		sw, ctn, err := c.getServiceWrapper(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		v, cl, err = sw.getWithClose(ctx, ctn)
+		if !errors.Is(err, errReplaced) {
+			return v, cl, err
+		}
+	}
+}
+
+func (c *Container) isInitialized(key Key) (bool, error) {
+	sw, _, err := c.getServiceWrapper(key)
+	if err != nil {
+		return false, c.wrapServiceError(err, key)
+	}
+	return sw.isInitialized(), nil
 }
 
 func (c *Container) getDependency(ctx context.Context, key Key) (d *Dependency, err error) {
-	defer wrapReturnServiceError(&err, key)
-	sw, err := c.services.get(key)
+	defer c.wrapReturnServiceError(&err, key)
+	err = c.drain.check(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return sw.getDependency(ctx, c)
+	for {
+		sw, ctn, err := c.getServiceWrapper(key)
+		if err != nil {
+			return nil, err
+		}
+		if ctn.config.dependencyTrackingDisabled {
+			return nil, ErrDependencyTrackingDisabled
+		}
+		d, err = sw.getDependency(ctx, ctn)
+		if !errors.Is(err, errReplaced) {
+			return d, err
+		}
+	}
+}
+
+// getServiceWrapper returns the [serviceWrapper] and the [Container] that owns it.
+// If the service is not set in this [Container], it falls back to the parent.
+func (c *Container) getServiceWrapper(key Key) (*serviceWrapper, *Container, error) {
+	sw, err := c.services.get(key)
+	if err == nil {
+		return sw, c, nil
+	}
+	if c.parent != nil && errors.Is(err, ErrNotSet) {
+		return c.parent.getServiceWrapper(key)
+	}
+	return nil, nil, err
 }
 
 func (c *Container) all(f func(key Key, sw *serviceWrapper)) {
 	c.services.all(f)
 }
 
+// allResolvable calls f for all the services that can be resolved from the [Container].
+// It includes the services of the parents that are not shadowed.
+func (c *Container) allResolvable(f func(key Key, sw *serviceWrapper)) {
+	if c.parent == nil {
+		c.all(f)
+		return
+	}
+	seen := make(map[Key]bool)
+	for ctn := c; ctn != nil; ctn = ctn.parent {
+		ctn.all(func(key Key, sw *serviceWrapper) {
+			if !seen[key] {
+				seen[key] = true
+				f(key, sw)
+			}
+		})
+	}
+}
+
+// Keys returns the keys of all the services set to the [Container], sorted by [Key.String].
+//
+// It doesn't initialize the services.
+func (c *Container) Keys() []Key {
+	keys := c.services.getKeys()
+	sortKeys(keys)
+	return keys
+}
+
+// ClosableKeys returns the keys of the services that have a [Close] function to call, sorted by [Key.String].
+//
+// They are the initialized services whose [Builder] returned a [Close] function, and the factory services that returned at least one.
+// It allows to check which services hold resources.
+// It doesn't initialize the services.
+// The services of the parents are not included.
+func (c *Container) ClosableKeys(ctx context.Context) ([]Key, error) {
+	var keys []Key
+	for _, sw := range c.services.getValues() {
+		ok, err := sw.hasClose(ctx)
+		if err != nil {
+			return nil, c.wrapServiceError(err, sw.key)
+		}
+		if ok {
+			keys = append(keys, sw.key)
+		}
+	}
+	sortKeys(keys)
+	return keys, nil
+}
+
+// Len returns the number of services set to the [Container].
+//
+// The services of the parents are not included.
+func (c *Container) Len() int {
+	return c.services.len()
+}
+
+// InitializedLen returns the number of services of the [Container] that are initialized.
+//
+// Factory services are not included.
+func (c *Container) InitializedLen() int {
+	n := 0
+	c.all(func(key Key, sw *serviceWrapper) {
+		if sw.isInitialized() {
+			n++
+		}
+	})
+	return n
+}
+
 // Close closes all the services of the [Container].
 //
+// The services are closed in the reverse order of their initialization, so a service is closed before its dependencies.
+//
 // The created services must not be used after this call.
 //
 // The [Container] can be used again after being closed.
+//
+// If it is called from a [Close] function, it returns [ErrCloseReentrant].
 func (c *Container) Close(ctx context.Context) error {
-	sws := c.services.getValues()
-	slices.SortFunc(sws, func(a, b *serviceWrapper) int {
-		return cmp.Compare(a.key.String(), b.key.String())
+	if isClosingContext(ctx) {
+		return ErrCloseReentrant
+	}
+	return c.closeServiceWrappers(ctx, c.getServiceWrappersCloseOrder())
+}
+
+// CloseWithTimeout is like [Container.Close], but each service must be closed within the timeout.
+//
+// The timeout applies to each service independently: a slow service doesn't reduce the time given to the others.
+// The deadline of ctx still applies to all the services.
+// If a service is not closed within the timeout, [context.DeadlineExceeded] is returned for this service, and the other services are closed.
+// The [Close] function keeps running in the background, see [Container.WaitForBackgroundTasks].
+func (c *Container) CloseWithTimeout(ctx context.Context, timeout time.Duration) error {
+	if isClosingContext(ctx) {
+		return ErrCloseReentrant
+	}
+	var errs []error
+	for _, sw := range c.getServiceWrappersCloseOrder() {
+		err := c.closeServiceWrapperWithTimeout(ctx, sw, timeout)
+		if err != nil {
+			err = c.wrapServiceError(err, sw.key)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Container) closeServiceWrapperWithTimeout(ctx context.Context, sw *serviceWrapper, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	goroutine.WaitGroup(ctx, &c.background, func(ctx context.Context) {
+		done <- sw.close(ctx, c)
 	})
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // We don't need to wrap.
+	}
+}
+
+// Reset closes all the services of the [Container], and removes them.
+//
+// Contrary to [Container.Close], the services are not set anymore: the [Container] is empty, as a new one.
+// The services are removed before being closed, so a concurrent [Get] doesn't initialize them again.
+// If the [Container] was closed by [Container.Shutdown] or [Container.Drain], it can be used again.
+//
+// If it is called from a [Close] function, it returns [ErrCloseReentrant].
+func (c *Container) Reset(ctx context.Context) error {
+	if isClosingContext(ctx) {
+		return ErrCloseReentrant
+	}
+	c.drain.reset()
+	sws := c.services.reset()
+	sortServiceWrappersCloseOrder(sws)
+	return c.closeServiceWrappers(ctx, sws)
+}
+
+func (c *Container) closeServiceWrappers(ctx context.Context, sws []*serviceWrapper) error {
 	var errs []error
 	for _, sw := range sws {
-		err := sw.close(ctx)
+		err := sw.close(ctx, c)
 		if err != nil {
-			err = wrapServiceError(err, sw.key)
+			err = c.wrapServiceError(err, sw.key)
 			errs = append(errs, err)
 		}
 	}
 	return errors.Join(errs...)
 }
 
+// CloseStream closes all the services of the [Container], and sends a [CloseEvent] for each of them to the returned channel.
+//
+// The services are closed in the same order as [Container.Close].
+// The channel is closed once all the services are closed.
+// It is buffered, so the caller is not required to receive all the events.
+func (c *Container) CloseStream(ctx context.Context) <-chan CloseEvent {
+	sws := c.getServiceWrappersCloseOrder()
+	ch := make(chan CloseEvent, len(sws))
+	goroutine.WaitGroup(ctx, &c.background, func(ctx context.Context) {
+		defer close(ch)
+		for _, sw := range sws {
+			start := time.Now()
+			err := sw.close(ctx, c)
+			ch <- CloseEvent{
+				Key:      sw.key,
+				Err:      c.wrapServiceError(err, sw.key),
+				Duration: time.Since(start),
+			}
+		}
+	})
+	return ch
+}
+
+// WaitForBackgroundTasks blocks until all the goroutines started in the background by the [Container] are terminated (e.g. by [Container.CloseStream]).
+//
+// It must be called after [Container.Close].
+// It allows to check that the [Container] doesn't leak goroutines, e.g. in tests.
+func (c *Container) WaitForBackgroundTasks(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		c.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // We don't need to wrap.
+	}
+}
+
+// getServiceWrappersCloseOrder returns the [serviceWrapper]s to close, in the close order.
+//
+// The persistent services are excluded, see [WithPersistent].
+func (c *Container) getServiceWrappersCloseOrder() []*serviceWrapper {
+	sws := c.services.getValues()
+	sws = slices.DeleteFunc(sws, func(sw *serviceWrapper) bool {
+		return sw.persistent
+	})
+	sortServiceWrappersCloseOrder(sws)
+	return sws
+}
+
+func sortServiceWrappersCloseOrder(sws []*serviceWrapper) {
+	slices.SortFunc(sws, func(a, b *serviceWrapper) int {
+		return cmp.Compare(b.sequence.Load(), a.sequence.Load())
+	})
+}
+
+// CloseEvent is sent by [Container.CloseStream] when a service is closed.
+type CloseEvent struct {
+	Key      Key
+	Err      error
+	Duration time.Duration
+}
+
+// NewChild returns a new child [Container].
+//
+// If a service is not set in the child, it is resolved from the parent.
+// Services set in the child shadow the services of the parent.
+// The services of the parent are built and owned by the parent: they don't see the services of the child.
+//
+// The child copies the hooks of the parent ([Container.OnEvent], [Container.Use], [Container.OnSLOBreach]) registered before the call.
+// The hooks registered later to the parent or the child are not shared.
+//
+// Closing the child only closes the services set in the child.
+// It allows to create short-lived containers, e.g. for a request.
+func (c *Container) NewChild() *Container {
+	ctn := &Container{
+		config: c.config,
+		parent: c,
+	}
+	c.copyHooks(ctn)
+	return ctn
+}
+
+// SnapshotRegistrations returns a new [Container] with the same services, not initialized.
+//
+// The builds in the new [Container] don't affect this [Container], so it allows to isolate tests that share a base [Container].
+// The [Builder]s, options and hooks are shared.
+// The parents are copied too, see [Container.NewChild].
+// The registration order is preserved.
+func (c *Container) SnapshotRegistrations() *Container {
+	return c.clone(true)
+}
+
+// clone returns a copy of the [Container] and its parents, with the same services, not initialized.
+//
+// If hooks is false, the hooks are not copied, and the [BuildHook] is removed, so they are not called for the builds of the copy.
+func (c *Container) clone(hooks bool) *Container {
+	ctn := &Container{
+		config: c.config,
+	}
+	if hooks {
+		c.copyHooks(ctn)
+	} else {
+		ctn.config.buildHook = nil
+	}
+	if c.parent != nil {
+		ctn.parent = c.parent.clone(hooks)
+	}
+	c.services.allOrdered(func(key Key, sw *serviceWrapper) {
+		_ = ctn.setServiceWrapper(newServiceWrapperFromConfig(sw.serviceConfig))
+	})
+	return ctn
+}
+
+// copyHooks copies the event handlers, the [BuilderMiddleware]s and the [SLOBreachHandler] to another [Container].
+func (c *Container) copyHooks(ctn *Container) {
+	ctn.events.hs = c.events.get()
+	ctn.middlewares.mws = c.middlewares.get()
+	ctn.onSLOBreach.Store(c.onSLOBreach.Load())
+}
+
+// Extract returns a new [Container] that contains only the services reachable from the roots.
+//
+// It initializes the roots in order to know their dependencies.
+// The services are set to the new [Container] with the same [Builder] and options, and are not initialized.
+// The hooks are copied, as in [Container.NewChild].
+func (c *Container) Extract(ctx context.Context, roots ...Key) (*Container, error) {
+	reachable := make(map[Key]bool)
+	for _, root := range roots {
+		dep, err := c.getDependency(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+		dep.walk(func(d *Dependency) bool {
+			if reachable[d.key] {
+				return false
+			}
+			reachable[d.key] = true
+			return true
+		})
+	}
+	ctn := &Container{
+		config: c.config,
+	}
+	c.copyHooks(ctn)
+	for key := range reachable {
+		sw, _, err := c.getServiceWrapper(key)
+		if err != nil {
+			return nil, c.wrapServiceError(err, key)
+		}
+		err = ctn.setServiceWrapper(newServiceWrapperFromConfig(sw.serviceConfig))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ctn, nil
+}
+
+// ReadOnly returns a [ReadOnlyContainer] view of the [Container].
+func (c *Container) ReadOnly() *ReadOnlyContainer {
+	return &ReadOnlyContainer{
+		ctn: c,
+	}
+}
+
+func (c *Container) container() *Container {
+	return c
+}
+
+// ReadOnlyContainer is a read-only view of a [Container].
+//
+// It only allows to get services, so it can be given to untrusted code.
+// It is not a copy: it shares the services of the [Container].
+type ReadOnlyContainer struct {
+	ctn *Container
+}
+
+func (r *ReadOnlyContainer) container() *Container {
+	return r.ctn
+}
+
+// Resolver resolves services.
+//
+// It is implemented by [Container] and [ReadOnlyContainer].
+type Resolver interface {
+	container() *Container
+}
+
 // Key represents a service key in a [Container].
+//
+// Version is 0 for services that are not versioned.
 type Key struct {
-	Type string
-	Name string
+	Type    string
+	Name    string
+	Version int
+}
+
+// KeyFor returns the [Key] of a service of type S.
+//
+// See [WithDependencies].
+func KeyFor[S any](name string) Key {
+	return newKey[S](name)
 }
 
 func newKey[S any](name string) Key {
@@ -78,9 +547,43 @@ func newKey[S any](name string) Key {
 	}
 }
 
+func sortKeys(keys []Key) {
+	slices.SortFunc(keys, func(a, b Key) int {
+		return cmp.Compare(a.String(), b.String())
+	})
+}
+
 func (k Key) String() string {
-	if k.Name == "" {
-		return k.Type
+	s := k.Type
+	if k.Name != "" {
+		s = fmt.Sprintf("%s(%s)", s, k.Name)
+	}
+	if k.Version != 0 {
+		s = fmt.Sprintf("%s@v%d", s, k.Version)
+	}
+	return s
+}
+
+type keyJSON struct {
+	Type    string `json:"type"`
+	Name    string `json:"name,omitempty"`
+	Version int    `json:"version,omitempty"`
+}
+
+// MarshalJSON implements [json.Marshaler].
+//
+// The type is the full name, as in [Dependency.Type] with the default type namer.
+func (k Key) MarshalJSON() ([]byte, error) {
+	return json.Marshal(keyJSON(k)) //nolint:wrapcheck // We don't need to wrap.
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (k *Key) UnmarshalJSON(data []byte) error {
+	var kj keyJSON
+	err := json.Unmarshal(data, &kj)
+	if err != nil {
+		return err //nolint:wrapcheck // We don't need to wrap.
 	}
-	return fmt.Sprintf("%s(%s)", k.Type, k.Name)
+	*k = Key(kj)
+	return nil
 }
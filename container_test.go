@@ -2,9 +2,13 @@ package di
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"fmt"
+	"slices"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/pierrre/assert"
 	"github.com/pierrre/go-libs/goroutine"
@@ -36,21 +40,212 @@ func TestContainerClose(t *testing.T) {
 func TestContainerCloseOrder(t *testing.T) {
 	ctx := context.Background()
 	ctn := new(Container)
-	count := 5
-	var closeCalls []int
-	for i := range count {
-		name := fmt.Sprintf("%05d", i)
-		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+	var closeCalls []string
+	newBuilder := func(name string, deps ...string) Builder[string] {
+		return func(ctx context.Context, ctn *Container) (string, Close, error) {
+			for _, dep := range deps {
+				MustGet[string](ctx, ctn, dep)
+			}
 			return "", func(ctx context.Context) error {
-				closeCalls = append(closeCalls, i)
+				closeCalls = append(closeCalls, name)
+				return nil
+			}, nil
+		}
+	}
+	MustSet(ctn, "a_db", newBuilder("a_db"))
+	MustSet(ctn, "b_repository", newBuilder("b_repository", "a_db"))
+	MustSet(ctn, "c_handler", newBuilder("c_handler", "b_repository"))
+	MustSet(ctn, "d_other", newBuilder("d_other"))
+	MustGet[string](ctx, ctn, "d_other")
+	MustGet[string](ctx, ctn, "c_handler")
+	err := ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, closeCalls, []string{"c_handler", "b_repository", "a_db", "d_other"})
+}
+
+func TestContainerCloseOrderConcurrent(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var mu sync.Mutex
+	var closed []Key
+	const count = 20
+	names := make([]string, count)
+	for i := range names {
+		names[i] = strconv.Itoa(i)
+		dep := ""
+		if i%2 == 1 {
+			dep = names[i-1]
+		}
+		key := newKey[string](names[i])
+		MustSet(ctn, names[i], func(ctx context.Context, ctn *Container) (string, Close, error) {
+			if dep != "" {
+				MustGet[string](ctx, ctn, dep)
+			}
+			time.Sleep(time.Duration(count-i) * 100 * time.Microsecond)
+			return "", func(ctx context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				closed = append(closed, key)
 				return nil
 			}, nil
 		})
+	}
+	goroutine.Slice(ctx, names, count, func(ctx context.Context, _ int, name string) struct{} {
 		MustGet[string](ctx, ctn, name)
+		return struct{}{}
+	})
+	order := ctn.InitializationOrder()
+	assert.SliceLen(t, order, count)
+	err := ctn.Close(ctx)
+	assert.NoError(t, err)
+	slices.Reverse(order)
+	assert.DeepEqual(t, closed, order)
+	for i := 1; i < count; i += 2 {
+		// The dependent is closed before its dependency.
+		assert.Less(t, slices.Index(closed, newKey[string](names[i])), slices.Index(closed, newKey[string](names[i-1])))
+	}
+}
+
+func TestContainerCloseWithTimeout(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	release := make(chan struct{})
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "a", func(ctx context.Context) error {
+			<-release // Ignores the context.
+			return nil
+		}, nil
+	})
+	bClosed := false
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "b", func(ctx context.Context) error {
+			bClosed = true
+			return nil
+		}, nil
+	})
+	MustGet[string](ctx, ctn, "b")
+	MustGet[string](ctx, ctn, "a")
+	err := ctn.CloseWithTimeout(ctx, 10*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	var serviceErr *ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+	assert.Equal(t, serviceErr.Key, newKey[string]("a"))
+	assert.True(t, bClosed)
+	close(release)
+	err = ctn.WaitForBackgroundTasks(ctx)
+	assert.NoError(t, err)
+}
+
+func TestContainerCloseWithTimeoutErrorReentrant(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			return ctn.CloseWithTimeout(ctx, 1*time.Second)
+		}, nil
+	})
+	MustGet[string](ctx, ctn, "")
+	err := ctn.CloseWithTimeout(ctx, 1*time.Second)
+	assert.ErrorIs(t, err, ErrCloseReentrant)
+}
+
+func TestContainerReset(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var closed []string
+	for _, name := range []string{"a", "b"} {
+		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			if name == "b" {
+				MustGet[string](ctx, ctn, "a")
+			}
+			return "", func(ctx context.Context) error {
+				closed = append(closed, name)
+				return nil
+			}, nil
+		})
 	}
+	MustGet[string](ctx, ctn, "b")
+	err := ctn.Reset(ctx)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, closed, []string{"b", "a"})
+	assert.SliceEmpty(t, ctn.Keys())
+	_, err = Get[string](ctx, ctn, "a")
+	assert.ErrorIs(t, err, ErrNotSet)
+	err = SetValue(ctn, "a", "")
+	assert.NoError(t, err)
+}
+
+func TestContainerResetError(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			return errors.New("error")
+		}, nil
+	})
+	MustGet[string](ctx, ctn, "")
+	err := ctn.Reset(ctx)
+	assert.ErrorEqual(t, err, "service string: error")
+	assert.SliceEmpty(t, ctn.Keys())
+}
+
+func TestContainerResetErrorReentrant(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			return ctn.Reset(ctx)
+		}, nil
+	})
+	MustGet[string](ctx, ctn, "")
 	err := ctn.Close(ctx)
+	assert.ErrorIs(t, err, ErrCloseReentrant)
+}
+
+func TestContainerCloseStream(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, nil
+	})
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "a")
+		return "", func(ctx context.Context) error {
+			return errors.New("error")
+		}, nil
+	})
+	MustGet[string](ctx, ctn, "b")
+	var keys []Key
+	var errs []error
+	for ev := range ctn.CloseStream(ctx) {
+		keys = append(keys, ev.Key)
+		errs = append(errs, ev.Err)
+	}
+	assert.DeepEqual(t, keys, []Key{newKey[string]("b"), newKey[string]("a")})
+	assert.ErrorEqual(t, errs[0], "service string(b): error")
+	assert.NoError(t, errs[1])
+}
+
+func TestContainerWaitForBackgroundTasks(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	block := make(chan struct{})
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			<-block
+			return nil
+		}, nil
+	})
+	MustGet[string](ctx, ctn, "")
+	ch := ctn.CloseStream(ctx)
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	err := ctn.WaitForBackgroundTasks(canceledCtx)
+	assert.ErrorIs(t, err, context.Canceled)
+	close(block)
+	err = ctn.WaitForBackgroundTasks(ctx)
 	assert.NoError(t, err)
-	assert.DeepEqual(t, closeCalls, []int{0, 1, 2, 3, 4})
+	assert.Equal(t, len(ch), 1)
 }
 
 func TestContainerCloseNil(t *testing.T) {
@@ -83,6 +278,77 @@ func TestContainerCloseNotInitialized(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestContainerCloseDependencyOfFailedBuild(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		_, err := Get[string](ctx, ctn, "b")
+		if err != nil {
+			return "", nil, err
+		}
+		_, err = Get[string](ctx, ctn, "c")
+		if err != nil {
+			return "", nil, err
+		}
+		return "a", nil, nil
+	})
+	bClosed := 0
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "b", func(ctx context.Context) error {
+			bClosed++
+			return nil
+		}, nil
+	})
+	MustSet(ctn, "c", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, errors.New("error")
+	})
+	_, err := Get[string](ctx, ctn, "a")
+	assert.Error(t, err)
+	err = ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, bClosed, 1)
+}
+
+func TestContainerCloseOrphanCloser(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closed := 0
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			closed++
+			return nil
+		}, errors.New("error")
+	})
+	for range 2 {
+		_, err := Get[string](ctx, ctn, "")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, closed, 0)
+	err := ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closed, 2)
+	err = ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closed, 2)
+}
+
+func TestContainerCloseOrphanCloserNilService(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closed := false
+	MustSetPtr(ctn, "", func(ctx context.Context, ctn *Container) (*string, Close, error) {
+		return nil, func(ctx context.Context) error {
+			closed = true
+			return nil
+		}, nil
+	})
+	_, err := Get[*string](ctx, ctn, "")
+	assert.ErrorIs(t, err, ErrNilService)
+	err = ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.True(t, closed)
+}
+
 func TestContainerCloseError(t *testing.T) {
 	ctx := context.Background()
 	ctn := new(Container)
@@ -99,6 +365,20 @@ func TestContainerCloseError(t *testing.T) {
 	assert.Equal(t, serviceErr.Key, newKey[string](""))
 }
 
+func TestContainerCloseErrorReentrant(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			return ctn.Close(ctx)
+		}, nil
+	})
+	MustGet[string](ctx, ctn, "")
+	err := ctn.Close(ctx)
+	assert.ErrorIs(t, err, ErrCloseReentrant)
+	assert.ErrorEqual(t, err, "service string: close reentrant")
+}
+
 func TestContainerCloseErrorServiceWrapperMutexContextCanceled(t *testing.T) {
 	ctx := context.Background()
 	ctn := new(Container)
@@ -120,3 +400,273 @@ func TestContainerCloseErrorServiceWrapperMutexContextCanceled(t *testing.T) {
 	err := ctn.Close(ctx)
 	assert.ErrorIs(t, err, context.Canceled)
 }
+
+func TestContainerKeys(t *testing.T) {
+	ctn := new(Container)
+	MustSetValue(ctn, "b", "")
+	MustSetValue(ctn, "a", "")
+	MustSetValue(ctn, "", 0)
+	keys := ctn.Keys()
+	assert.DeepEqual(t, keys, []Key{newKey[int](""), newKey[string]("a"), newKey[string]("b")})
+}
+
+func TestContainerClosableKeys(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	newCloseBuilder := func(s string) Builder[string] {
+		return func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return s, func(ctx context.Context) error {
+				return nil
+			}, nil
+		}
+	}
+	MustSet(ctn, "a", newCloseBuilder("a"))
+	MustSet(ctn, "b", newCloseBuilder("b"))
+	MustSetValue(ctn, "c", "c")
+	MustSetFactory(ctn, "d", newCloseBuilder("d"))
+	keys, err := ctn.ClosableKeys(ctx)
+	assert.NoError(t, err)
+	assert.SliceEmpty(t, keys)
+	MustGet[string](ctx, ctn, "a")
+	MustGet[string](ctx, ctn, "c")
+	MustGet[string](ctx, ctn, "d")
+	keys, err = ctn.ClosableKeys(ctx)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, keys, []Key{newKey[string]("a"), newKey[string]("d")})
+	err = ctn.Close(ctx)
+	assert.NoError(t, err)
+	keys, err = ctn.ClosableKeys(ctx)
+	assert.NoError(t, err)
+	assert.SliceEmpty(t, keys)
+}
+
+func TestContainerClosableKeysErrorCycle(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		_, err := ctn.ClosableKeys(ctx)
+		return "", nil, err
+	})
+	_, err := Get[string](ctx, ctn, "")
+	assert.ErrorIs(t, err, ErrCycle)
+}
+
+func TestContainerLen(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	assert.Equal(t, ctn.Len(), 0)
+	assert.Equal(t, ctn.InitializedLen(), 0)
+	MustSetValue(ctn, "a", "")
+	MustSetValue(ctn, "b", "")
+	MustSetFactory(ctn, "", func(ctx context.Context, ctn *Container) (int, Close, error) {
+		return 0, nil, nil
+	})
+	assert.Equal(t, ctn.Len(), 3)
+	assert.Equal(t, ctn.InitializedLen(), 0)
+	MustGet[string](ctx, ctn, "a")
+	MustGet[int](ctx, ctn, "")
+	assert.Equal(t, ctn.InitializedLen(), 1)
+	err := ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, ctn.Len(), 3)
+	assert.Equal(t, ctn.InitializedLen(), 0)
+}
+
+func TestContainerNewChild(t *testing.T) {
+	ctx := context.Background()
+	parent := new(Container)
+	MustSetValue(parent, "a", "parent")
+	MustSetValue(parent, "b", "parent")
+	child := parent.NewChild()
+	MustSetValue(child, "b", "child")
+	s := MustGet[string](ctx, child, "a")
+	assert.Equal(t, s, "parent")
+	s = MustGet[string](ctx, child, "b")
+	assert.Equal(t, s, "child")
+	s = MustGet[string](ctx, parent, "b")
+	assert.Equal(t, s, "parent")
+	_, err := Get[string](ctx, child, "c")
+	assert.ErrorIs(t, err, ErrNotSet)
+}
+
+func TestContainerNewChildClose(t *testing.T) {
+	ctx := context.Background()
+	parent := new(Container)
+	parentClosed := false
+	MustSet(parent, "parent", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			parentClosed = true
+			return nil
+		}, nil
+	})
+	child := parent.NewChild()
+	childClosed := false
+	MustSet(child, "child", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "parent")
+		return "", func(ctx context.Context) error {
+			childClosed = true
+			return nil
+		}, nil
+	})
+	MustGet[string](ctx, child, "child")
+	err := child.Close(ctx)
+	assert.NoError(t, err)
+	assert.True(t, childClosed)
+	assert.False(t, parentClosed)
+	err = parent.Close(ctx)
+	assert.NoError(t, err)
+	assert.True(t, parentClosed)
+}
+
+func TestContainerNewChildGetAll(t *testing.T) {
+	ctx := context.Background()
+	parent := new(Container)
+	MustSetValue(parent, "a", "parent")
+	MustSetValue(parent, "b", "parent")
+	MustSetVersioned(parent, "v", 1, newValueBuilder("parent"))
+	child := parent.NewChild()
+	MustSetValue(child, "b", "child")
+	MustSetValue(child, "c", "child")
+	ss, err := GetAll[string](ctx, child)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, ss, map[string]string{"a": "parent", "b": "child", "c": "child"})
+	assert.DeepEqual(t, GetNames[string](child), []string{"a", "b", "c"})
+	assert.DeepEqual(t, GetNames[string](parent), []string{"a", "b"})
+	s, err := GetLatestVersion[string](ctx, child, "v")
+	assert.NoError(t, err)
+	assert.Equal(t, s, "parent")
+}
+
+func TestContainerNewChildHooks(t *testing.T) {
+	ctx := context.Background()
+	parent := new(Container)
+	var calls []string
+	parent.OnEvent(func(ev Event) {
+		calls = append(calls, "event "+ev.Type.String())
+	})
+	parent.Use(func(key Key, next BuildFunc) BuildFunc {
+		return func(ctx context.Context, ctn *Container) (any, Close, error) {
+			calls = append(calls, "middleware")
+			return next(ctx, ctn)
+		}
+	})
+	parent.OnSLOBreach(func(key Key, actual, sla time.Duration) {
+		calls = append(calls, "slo")
+	})
+	child := parent.NewChild()
+	parent.OnEvent(func(ev Event) {
+		calls = append(calls, "parent only")
+	})
+	MustSet(child, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		time.Sleep(1 * time.Millisecond)
+		return "", nil, nil
+	}, WithBuildSLA(1*time.Nanosecond))
+	MustGet[string](ctx, child, "")
+	assert.DeepEqual(t, calls, []string{"event build_start", "middleware", "event build_end", "slo"})
+}
+
+func TestContainerSnapshotRegistrationsHooks(t *testing.T) {
+	ctx := context.Background()
+	base := new(Container)
+	events := 0
+	base.OnEvent(func(ev Event) {
+		events++
+	})
+	MustSetValue(base, "", "")
+	snapshot := base.SnapshotRegistrations()
+	MustGet[string](ctx, snapshot, "")
+	assert.Equal(t, events, 2)
+}
+
+func TestContainerSnapshotRegistrations(t *testing.T) {
+	ctx := context.Background()
+	parent := new(Container)
+	MustSetValue(parent, "p", "p")
+	base := parent.NewChild()
+	builderCalled := 0
+	MustSet(base, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		builderCalled++
+		return MustGet[string](ctx, ctn, "p") + strconv.Itoa(builderCalled), nil, nil
+	})
+	MustSetValue(base, "a", "a")
+	snapshot := base.SnapshotRegistrations()
+	assert.DeepEqual(t, getNamesInRegistrationOrder[string](snapshot), []string{"p", "b", "a"})
+	assert.Equal(t, MustGet[string](ctx, snapshot, "b"), "p1")
+	assert.Equal(t, base.InitializedLen(), 0)
+	assert.Equal(t, parent.InitializedLen(), 0)
+	assert.Equal(t, MustGet[string](ctx, base, "b"), "p2")
+	MustSetValue(snapshot, "c", "c")
+	assert.Equal(t, base.Len(), 2)
+}
+
+func TestContainerExtract(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "a" + MustGet[string](ctx, ctn, "b"), nil, nil
+	})
+	MustSetValue(ctn, "b", "b")
+	MustSetValue(ctn, "c", "c")
+	extracted, err := ctn.Extract(ctx, newKey[string]("a"))
+	assert.NoError(t, err)
+	assert.DeepEqual(t, extracted.Keys(), []Key{newKey[string]("a"), newKey[string]("b")})
+	s := MustGet[string](ctx, extracted, "a")
+	assert.Equal(t, s, "ab")
+}
+
+func TestContainerExtractErrorNotSet(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	_, err := ctn.Extract(ctx, newKey[string]("a"))
+	assert.ErrorIs(t, err, ErrNotSet)
+}
+
+func TestContainerReadOnly(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	ro := ctn.ReadOnly()
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "test", nil, nil
+	})
+	s, err := Get[string](ctx, ro, "")
+	assert.NoError(t, err)
+	assert.Equal(t, s, "test")
+	ss, err := GetAll[string](ctx, ro)
+	assert.NoError(t, err)
+	assert.MapLen(t, ss, 1)
+}
+
+func TestKeyJSON(t *testing.T) {
+	for _, tc := range []struct {
+		key      Key
+		expected string
+	}{
+		{key: newKey[string](""), expected: `{"type":"string"}`},
+		{key: newKey[*Container]("a"), expected: `{"type":"*github.com/pierrre/di.Container","name":"a"}`},
+		{key: Key{Type: "int", Name: "a", Version: 2}, expected: `{"type":"int","name":"a","version":2}`},
+	} {
+		data, err := json.Marshal(tc.key)
+		assert.NoError(t, err)
+		assert.Equal(t, string(data), tc.expected)
+		var key Key
+		err = json.Unmarshal(data, &key)
+		assert.NoError(t, err)
+		assert.Equal(t, key, tc.key)
+	}
+}
+
+func TestKeyUnmarshalJSONError(t *testing.T) {
+	var key Key
+	err := json.Unmarshal([]byte(`{"type":1}`), &key)
+	assert.Error(t, err)
+}
+
+func TestServiceErrorJSON(t *testing.T) {
+	err := &ServiceError{
+		error: ErrNotSet,
+		Key:   newKey[string]("a"),
+	}
+	data, jsonErr := json.Marshal(err)
+	assert.NoError(t, jsonErr)
+	assert.Equal(t, string(data), `{"Key":{"type":"string","name":"a"}}`)
+}
@@ -0,0 +1,103 @@
+package di
+
+import (
+	"context"
+	"errors"
+)
+
+// Decorator decorates a service.
+//
+// It receives the service built by the [Builder], and returns the decorated service.
+// The [Close] function is called before the [Close] function of the decorated service.
+type Decorator[S any] func(ctx context.Context, ctn *Container, s S) (S, Close, error)
+
+// Decorate decorates a service of a [Container].
+//
+// The service is built by its [Builder], then given to the [Decorator].
+// Multiple decorators are applied in the order of the calls.
+//
+// If the service is not set in the [Container], it returns [ErrNotSet].
+// If the service is already initialized, it returns [ErrAlreadyInitialized].
+func Decorate[S any](ctn *Container, name string, d Decorator[S]) error {
+	key := newKey[S](name)
+	return ctn.decorate(key, func(b builder) builder {
+		return newDecoratorBuilder(b, d)
+	})
+}
+
+// MustDecorate calls [Decorate] and panics if there is an error.
+func MustDecorate[S any](ctn *Container, name string, d Decorator[S]) {
+	err := Decorate(ctn, name, d)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (c *Container) decorate(key Key, f func(b builder) builder) (err error) {
+	defer c.wrapReturnServiceError(&err, key)
+	return c.services.update(key, func(sw *serviceWrapper) (*serviceWrapper, error) {
+		// The mutex is not awaited, because a build in progress could need the services lock held by update.
+		if !sw.mu.tryAcquire() {
+			return nil, ErrAlreadyInitialized
+		}
+		defer sw.mu.unlock()
+		if sw.sequence.Load() != 0 {
+			return nil, ErrAlreadyInitialized
+		}
+		cfg := sw.serviceConfig
+		cfg.builder = f(cfg.builder)
+		nsw := newServiceWrapperFromConfig(cfg)
+		// The orphan closers of the failed builds are kept, so they are still called by [Container.Close].
+		nsw.closers = sw.closers
+		sw.closers = nil
+		return nsw, nil
+	})
+}
+
+func newDecoratorBuilder[S any](b builder, d Decorator[S]) builder {
+	return func(ctx context.Context, ctn *Container) (any, Close, error) {
+		v, cl, err := b(ctx, ctn)
+		if err != nil {
+			return nil, cl, err
+		}
+		s, err := assertServiceType[S](v)
+		if err != nil {
+			return nil, cl, err
+		}
+		s, dcl, err := d(ctx, ctn, s)
+		if err != nil {
+			if cl != nil {
+				err = errors.Join(err, cl(ctx))
+			}
+			return nil, nil, err
+		}
+		return s, chainCloses(dcl, cl), nil
+	}
+}
+
+// chainCloses returns a [Close] function that calls the [Close] functions in order.
+// The nil [Close] functions are ignored.
+func chainCloses(cls ...Close) Close {
+	var nonNil []Close
+	for _, cl := range cls {
+		if cl != nil {
+			nonNil = append(nonNil, cl)
+		}
+	}
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	}
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, cl := range nonNil {
+			err := cl(ctx)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
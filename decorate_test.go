@@ -0,0 +1,140 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestDecorate(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var closes []string
+	newClose := func(name string) Close {
+		return func(ctx context.Context) error {
+			closes = append(closes, name)
+			return nil
+		}
+	}
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "service", newClose("service"), nil
+	})
+	err := Decorate(ctn, "", func(ctx context.Context, ctn *Container, s string) (string, Close, error) {
+		return s + " decorator1", newClose("decorator1"), nil
+	})
+	assert.NoError(t, err)
+	MustDecorate(ctn, "", func(ctx context.Context, ctn *Container, s string) (string, Close, error) {
+		return s + " decorator2", newClose("decorator2"), nil
+	})
+	MustDecorate(ctn, "", func(ctx context.Context, ctn *Container, s string) (string, Close, error) {
+		return s + " decorator3", nil, nil
+	})
+	s := MustGet[string](ctx, ctn, "")
+	assert.Equal(t, s, "service decorator1 decorator2 decorator3")
+	err = ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, closes, []string{"decorator2", "decorator1", "service"})
+}
+
+func TestDecorateErrorNotSet(t *testing.T) {
+	ctn := new(Container)
+	d := func(ctx context.Context, ctn *Container, s string) (string, Close, error) {
+		return s, nil, nil
+	}
+	err := Decorate(ctn, "", d)
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.ErrorEqual(t, err, "service string: not set")
+	assert.Panics(t, func() {
+		MustDecorate(ctn, "", d)
+	})
+}
+
+func TestDecorateErrorAlreadyInitialized(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "", "")
+	MustGet[string](ctx, ctn, "")
+	err := Decorate(ctn, "", func(ctx context.Context, ctn *Container, s string) (string, Close, error) {
+		return s, nil, nil
+	})
+	assert.ErrorIs(t, err, ErrAlreadyInitialized)
+}
+
+func TestDecorateErrorDecorator(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closed := false
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			closed = true
+			return nil
+		}, nil
+	})
+	MustDecorate(ctn, "", func(ctx context.Context, ctn *Container, s string) (string, Close, error) {
+		return "", nil, errors.New("error")
+	})
+	_, err := Get[string](ctx, ctn, "")
+	assert.ErrorEqual(t, err, "service string: error")
+	assert.True(t, closed)
+}
+
+func TestDecorateErrorBuilder(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, errors.New("error")
+	})
+	decoratorCalled := false
+	MustDecorate(ctn, "", func(ctx context.Context, ctn *Container, s string) (string, Close, error) {
+		decoratorCalled = true
+		return s, nil, nil
+	})
+	_, err := Get[string](ctx, ctn, "")
+	assert.ErrorEqual(t, err, "service string: error")
+	assert.False(t, decoratorCalled)
+}
+
+func TestDecorateNilInterface(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue[error](ctn, "", nil)
+	MustDecorate(ctn, "", func(ctx context.Context, ctn *Container, err error) (error, Close, error) {
+		assert.NoError(t, err)
+		return errors.New("decorated"), nil, nil
+	})
+	err := MustGet[error](ctx, ctn, "")
+	assert.ErrorEqual(t, err, "decorated")
+}
+
+func TestDecorateKeepFailedBuildClose(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closed := false
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			closed = true
+			return nil
+		}, errors.New("error")
+	})
+	_, err := Get[string](ctx, ctn, "")
+	assert.Error(t, err)
+	MustDecorate(ctn, "", func(ctx context.Context, ctn *Container, s string) (string, Close, error) {
+		return s, nil, nil
+	})
+	err = ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.True(t, closed)
+}
+
+func TestChainClosesError(t *testing.T) {
+	ctx := context.Background()
+	cl := chainCloses(func(ctx context.Context) error {
+		return errors.New("error1")
+	}, nil, func(ctx context.Context) error {
+		return errors.New("error2")
+	})
+	err := cl(ctx)
+	assert.ErrorEqual(t, err, "error1\nerror2")
+}
@@ -2,29 +2,203 @@ package di
 
 import (
 	"context"
+	"encoding/json"
 	"reflect"
+	"slices"
 	"sync"
 )
 
-// GetDependency returns a service [Dependency] tree from a [Container].
-func GetDependency[S any](ctx context.Context, ctn *Container, name string) (dep *Dependency, err error) {
+// GetDependency returns a service [Dependency] tree from a [Resolver].
+func GetDependency[S any](ctx context.Context, ctn Resolver, name string) (dep *Dependency, err error) {
 	key := newKey[S](name)
-	return ctn.getDependency(ctx, key)
+	return ctn.container().getDependency(ctx, key)
+}
+
+// MustGetDependency calls [GetDependency] and panics if there is an error.
+func MustGetDependency[S any](ctx context.Context, ctn Resolver, name string) *Dependency {
+	dep, err := GetDependency[S](ctx, ctn, name)
+	if err != nil {
+		panic(err)
+	}
+	return dep
+}
+
+// GetDependencyGraph returns the [Dependency] trees of all the services of the [Container], sorted by [Key.String].
+//
+// It initializes the services.
+// Shared dependencies appear in multiple trees.
+func (c *Container) GetDependencyGraph(ctx context.Context) ([]*Dependency, error) {
+	keys := c.Keys()
+	deps := make([]*Dependency, len(keys))
+	for i, key := range keys {
+		dep, err := c.getDependency(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		deps[i] = dep
+	}
+	return deps, nil
+}
+
+// GetDependents returns the keys of the services that depend on a service, directly or transitively, sorted by [Key.String].
+//
+// It doesn't initialize the services: only the dependencies of the initialized services are known.
+func GetDependents[S any](ctx context.Context, ctn Resolver, name string) (keys []Key, err error) {
+	key := newKey[S](name)
+	return ctn.container().getDependents(ctx, key)
+}
+
+func (c *Container) getDependents(ctx context.Context, key Key) (keys []Key, err error) {
+	_, _, err = c.getServiceWrapper(key)
+	if err != nil {
+		return nil, c.wrapServiceError(err, key)
+	}
+	dependents := make(map[Key][]Key)
+	for _, sw := range c.services.getValues() {
+		depKeys, err := sw.getDependencyKeys(ctx)
+		if err != nil {
+			return nil, c.wrapServiceError(err, sw.key)
+		}
+		for _, depKey := range depKeys {
+			dependents[depKey] = append(dependents[depKey], sw.key)
+		}
+	}
+	visited := make(map[Key]bool)
+	queue := []Key{key}
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		for _, dk := range dependents[k] {
+			if !visited[dk] {
+				visited[dk] = true
+				keys = append(keys, dk)
+				queue = append(queue, dk)
+			}
+		}
+	}
+	sortKeys(keys)
+	return keys, nil
 }
 
 // Dependency represents a service dependency.
+//
+// It can be encoded to and decoded from JSON.
+// The reflect.Type is not preserved by decoding.
 type Dependency struct {
-	Type         string `json:"type"`
-	reflectType  reflect.Type
+	key         Key
+	Type        string
+	reflectType reflect.Type
+	Name        string
+	Version     int
+	// Optional is true if the dependency is resolved through a [Provider].
+	// It is a soft dependency, that can be used to break a cycle.
+	Optional     bool
+	Dependencies []*Dependency
+}
+
+type dependencyJSON struct {
+	Type         string        `json:"type"`
 	Name         string        `json:"name,omitempty"`
+	Version      int           `json:"version,omitempty"`
+	Optional     bool          `json:"optional,omitempty"`
 	Dependencies []*Dependency `json:"dependencies,omitempty"`
 }
 
+// MarshalJSON implements [json.Marshaler].
+func (d *Dependency) MarshalJSON() ([]byte, error) {
+	return json.Marshal(dependencyJSON{ //nolint:wrapcheck // We don't need to wrap.
+		Type:         d.Type,
+		Name:         d.Name,
+		Version:      d.Version,
+		Optional:     d.Optional,
+		Dependencies: d.Dependencies,
+	})
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (d *Dependency) UnmarshalJSON(data []byte) error {
+	var dj dependencyJSON
+	err := json.Unmarshal(data, &dj)
+	if err != nil {
+		return err //nolint:wrapcheck // We don't need to wrap.
+	}
+	*d = Dependency{
+		key: Key{
+			Type:    dj.Type,
+			Name:    dj.Name,
+			Version: dj.Version,
+		},
+		Type:         dj.Type,
+		Name:         dj.Name,
+		Version:      dj.Version,
+		Optional:     dj.Optional,
+		Dependencies: dj.Dependencies,
+	}
+	return nil
+}
+
+// Equal returns true if the [Dependency] trees have the same exported fields, recursively.
+//
+// The reflect.Type is ignored.
+func (d *Dependency) Equal(other *Dependency) bool {
+	if d == nil || other == nil {
+		return d == other
+	}
+	return d.Type == other.Type &&
+		d.Name == other.Name &&
+		d.Version == other.Version &&
+		d.Optional == other.Optional &&
+		slices.EqualFunc(d.Dependencies, other.Dependencies, (*Dependency).Equal)
+}
+
 // GetReflectType returns the reflect.Type of the dependency.
 func (d *Dependency) GetReflectType() reflect.Type {
 	return d.reflectType
 }
 
+// walk calls f for the [Dependency] and its dependencies, depth-first.
+// If f returns false, the dependencies of the current [Dependency] are skipped.
+func (d *Dependency) walk(f func(d *Dependency) bool) {
+	if !f(d) {
+		return
+	}
+	for _, dd := range d.Dependencies {
+		dd.walk(f)
+	}
+}
+
+// Flatten returns the [Key] of each unique service of the [Dependency] tree, dependencies first.
+//
+// The root is the last [Key].
+// It can be used as an initialization order.
+func (d *Dependency) Flatten() []Key {
+	var keys []Key
+	d.flatten(make(map[Key]bool), &keys)
+	return keys
+}
+
+func (d *Dependency) flatten(visited map[Key]bool, keys *[]Key) {
+	k := d.getKey()
+	if visited[k] {
+		return
+	}
+	visited[k] = true
+	for _, dd := range d.Dependencies {
+		dd.flatten(visited, keys)
+	}
+	*keys = append(*keys, k)
+}
+
+// getKey returns the [Key] of the service.
+//
+// If the [Dependency] was not returned by the [Container] or decoded, the [Key] is reconstructed from the exported fields.
+func (d *Dependency) getKey() Key {
+	if d.key != (Key{}) {
+		return d.key
+	}
+	return d.exportedKey()
+}
+
 type dependencyCollector struct {
 	mu           sync.Mutex
 	dependencies []*Dependency
@@ -36,17 +210,48 @@ func (dc *dependencyCollector) add(d *Dependency) {
 	dc.dependencies = append(dc.dependencies, d)
 }
 
-type dependencyCollectorContextKey struct{}
-
+// addDependencyCollectorToContext returns a [context.Context] with a new dependency collector.
+//
+// The current [buildState] is copied, so the cycle detection is not affected.
 func addDependencyCollectorToContext(ctx context.Context) (context.Context, *dependencyCollector) {
-	c := &dependencyCollector{}
-	ctx = context.WithValue(ctx, dependencyCollectorContextKey{}, c)
-	return ctx, c
+	var st buildState
+	if previous := getBuildState(ctx); previous != nil {
+		st = *previous
+	}
+	st.collector = &dependencyCollector{}
+	return withBuildState(ctx, st), st.collector
 }
 
+// addDependencyToCollectorFromContext adds the [Dependency] to the nearest dependency collector.
+//
+// If the dependency was marked as optional for this collector, an optional copy is added.
+// A nil [Dependency] (the tracking is disabled) is ignored.
 func addDependencyToCollectorFromContext(ctx context.Context, d *Dependency) {
-	dc, ok := ctx.Value(dependencyCollectorContextKey{}).(*dependencyCollector)
-	if ok {
-		dc.add(d)
+	if d == nil {
+		return
+	}
+	for st := getBuildState(ctx); st != nil; st = st.previous {
+		if st.collector != nil {
+			if opt, _ := ctx.Value(optionalDependencyContextKey{}).(*buildState); opt == st {
+				od := *d
+				od.Optional = true
+				d = &od
+			}
+			st.collector.add(d)
+			return
+		}
+	}
+}
+
+type optionalDependencyContextKey struct{}
+
+// setOptionalDependencyToContext marks the dependency resolved with the [context.Context] as optional.
+//
+// It only applies to the current build, not to the dependencies of the dependency.
+func setOptionalDependencyToContext(ctx context.Context) context.Context {
+	st := getBuildState(ctx)
+	if st == nil {
+		return ctx
 	}
+	return context.WithValue(ctx, optionalDependencyContextKey{}, st)
 }
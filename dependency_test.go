@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/pierrre/assert"
@@ -37,14 +39,11 @@ func ExampleDependency() {
 	MustSet(ctn, "e", func(ctx context.Context, ctn *Container) (string, Close, error) {
 		return "", nil, nil
 	})
-	dep, err := GetDependency[string](ctx, ctn, "a")
-	if err != nil {
-		panic(err)
-	}
+	dep := MustGetDependency[string](ctx, ctn, "a")
 	buf := new(bytes.Buffer)
 	enc := json.NewEncoder(buf)
 	enc.SetIndent("", "\t")
-	err = enc.Encode(dep)
+	err := enc.Encode(dep)
 	if err != nil {
 		panic(err)
 	}
@@ -86,6 +85,14 @@ func ExampleDependency() {
 	// }
 }
 
+func TestMustGetDependencyPanic(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	assert.Panics(t, func() {
+		MustGetDependency[string](ctx, ctn, "")
+	})
+}
+
 func TestGetDependency(t *testing.T) {
 	ctx := context.Background()
 	ctn := new(Container)
@@ -114,21 +121,25 @@ func TestGetDependency(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotZero(t, dep.GetReflectType())
 	expected := &Dependency{
+		key:         newKey[string]("a"),
 		Type:        "string",
 		reflectType: reflect.TypeFor[string](),
 		Name:        "a",
 		Dependencies: []*Dependency{
 			{
+				key:         newKey[string]("b"),
 				Type:        "string",
 				Name:        "b",
 				reflectType: reflect.TypeFor[string](),
 				Dependencies: []*Dependency{
 					{
+						key:         newKey[string]("d"),
 						Type:        "string",
 						reflectType: reflect.TypeFor[string](),
 						Name:        "d",
 					},
 					{
+						key:         newKey[string]("e"),
 						Type:        "string",
 						reflectType: reflect.TypeFor[string](),
 						Name:        "e",
@@ -136,16 +147,19 @@ func TestGetDependency(t *testing.T) {
 				},
 			},
 			{
+				key:         newKey[string]("c"),
 				Type:        "string",
 				reflectType: reflect.TypeFor[string](),
 				Name:        "c",
 				Dependencies: []*Dependency{
 					{
+						key:         newKey[string]("d"),
 						Type:        "string",
 						reflectType: reflect.TypeFor[string](),
 						Name:        "d",
 					},
 					{
+						key:         newKey[string]("e"),
 						Type:        "string",
 						reflectType: reflect.TypeFor[string](),
 						Name:        "e",
@@ -157,6 +171,123 @@ func TestGetDependency(t *testing.T) {
 	assert.DeepEqual(t, dep, expected)
 }
 
+func TestDependencyJSON(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "b")
+		return "", nil, nil
+	})
+	MustSetVersioned(ctn, "b", 2, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, nil
+	})
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGetVersioned[string](ctx, ctn, "b", 2)
+		return "", nil, nil
+	})
+	dep, err := GetDependency[string](ctx, ctn, "a")
+	assert.NoError(t, err)
+	data, err := json.Marshal(dep)
+	assert.NoError(t, err)
+	decoded := new(Dependency)
+	err = json.Unmarshal(data, decoded)
+	assert.NoError(t, err)
+	assert.True(t, decoded.Equal(dep))
+	assert.Zero(t, decoded.GetReflectType())
+	assert.Equal(t, decoded.Dependencies[0].Dependencies[0].Version, 2)
+	assert.Equal(t, decoded.Dependencies[0].Dependencies[0].key, Key{Type: "string", Name: "b", Version: 2})
+}
+
+func TestGetDependencyOptional(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		p := MustGetProvider[string](ctx, ctn, "b")
+		p.MustGet(ctx)
+		MustGet[string](ctx, ctn, "c")
+		return "", nil, nil
+	})
+	MustSetProvider[string](ctn, "b")
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "c")
+		return "", nil, nil
+	})
+	MustSetValue(ctn, "c", "")
+	dep, err := GetDependency[string](ctx, ctn, "a")
+	assert.NoError(t, err)
+	assert.SliceLen(t, dep.Dependencies, 3)
+	assert.False(t, dep.Dependencies[0].Optional) // Provider.
+	b := dep.Dependencies[1]
+	assert.Equal(t, b.Name, "b")
+	assert.True(t, b.Optional)
+	assert.False(t, b.Dependencies[0].Optional)
+	assert.False(t, dep.Dependencies[2].Optional)
+	dep, err = GetDependency[string](ctx, ctn, "b")
+	assert.NoError(t, err)
+	assert.False(t, dep.Optional)
+	data, err := json.Marshal(dep.Dependencies[0])
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `{"type":"string","name":"c"}`)
+	data, err = json.Marshal(b)
+	assert.NoError(t, err)
+	assert.StringContains(t, string(data), `"optional":true`)
+	decoded := new(Dependency)
+	err = json.Unmarshal(data, decoded)
+	assert.NoError(t, err)
+	assert.True(t, decoded.Equal(b))
+}
+
+func TestDependencyFlatten(t *testing.T) {
+	deps := newTestDependencyGraph(t)
+	keys := deps[1].Flatten()
+	assert.DeepEqual(t, keys, []Key{newKey[*int](""), newKey[string]("b"), newKey[string]("a")})
+}
+
+func TestDependencyFlattenExported(t *testing.T) {
+	dep := &Dependency{
+		Type: "string",
+		Name: "a",
+		Dependencies: []*Dependency{
+			{Type: "int", Version: 2},
+			{Type: "int", Version: 2},
+		},
+	}
+	keys := dep.Flatten()
+	assert.DeepEqual(t, keys, []Key{{Type: "int", Version: 2}, {Type: "string", Name: "a"}})
+}
+
+func TestDependencyUnmarshalJSONError(t *testing.T) {
+	d := new(Dependency)
+	err := json.Unmarshal([]byte(`{"type":1}`), d)
+	assert.Error(t, err)
+}
+
+func TestDependencyEqual(t *testing.T) {
+	a := &Dependency{
+		Type: "string",
+		Name: "a",
+		Dependencies: []*Dependency{
+			{Type: "int"},
+		},
+	}
+	b := &Dependency{
+		Type:        "string",
+		Name:        "a",
+		reflectType: reflect.TypeFor[string](),
+		Dependencies: []*Dependency{
+			{Type: "int"},
+		},
+	}
+	assert.True(t, a.Equal(b))
+	b.Dependencies[0].Optional = true
+	assert.False(t, a.Equal(b))
+	b.Dependencies[0].Optional = false
+	b.Dependencies[0].Name = "b"
+	assert.False(t, a.Equal(b))
+	assert.False(t, a.Equal(nil))
+	assert.True(t, (*Dependency)(nil).Equal(nil))
+}
+
 func TestGetDependencyErrorNotSet(t *testing.T) {
 	ctx := context.Background()
 	ctn := new(Container)
@@ -186,7 +317,7 @@ func TestGetDependencyErrorCycle(t *testing.T) {
 	ctn := newTestContainerCycle()
 	_, err := GetDependency[string](ctx, ctn, "a")
 	assert.ErrorIs(t, err, ErrCycle)
-	assert.ErrorEqual(t, err, "service string(a): service string(b): service string(c): service string(a): cycle")
+	assert.ErrorEqual(t, err, "service string(a): service string(b): service string(c): service string(a): cycle: string(a) -> string(b) -> string(c) -> string(a)")
 }
 
 func TestGetDependencyErrorServiceWrapperMutexContextCanceled(t *testing.T) {
@@ -210,3 +341,109 @@ func TestGetDependencyErrorServiceWrapperMutexContextCanceled(t *testing.T) {
 	_, err := GetDependency[string](ctx, ctn, "")
 	assert.ErrorIs(t, err, context.Canceled)
 }
+
+func TestGetDependents(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "b")
+		MustGet[string](ctx, ctn, "c")
+		return "", nil, nil
+	})
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "d")
+		return "", nil, nil
+	})
+	MustSet(ctn, "c", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "d")
+		return "", nil, nil
+	})
+	MustSetValue(ctn, "d", "")
+	MustSet(ctn, "e", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "d")
+		return "", nil, nil
+	})
+	MustGet[string](ctx, ctn, "a")
+	keys, err := GetDependents[string](ctx, ctn, "d")
+	assert.NoError(t, err)
+	assert.DeepEqual(t, keys, []Key{newKey[string]("a"), newKey[string]("b"), newKey[string]("c")})
+	keys, err = GetDependents[string](ctx, ctn, "a")
+	assert.NoError(t, err)
+	assert.SliceEmpty(t, keys)
+}
+
+func TestGetDependentsErrorNotSet(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	_, err := GetDependents[string](ctx, ctn, "")
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.ErrorEqual(t, err, "service string: not set")
+}
+
+func TestContainerGetDependencyGraph(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "b")
+		return "", nil, nil
+	})
+	MustSetValue(ctn, "b", "")
+	MustSetValue(ctn, "", 0)
+	deps, err := ctn.GetDependencyGraph(ctx)
+	assert.NoError(t, err)
+	assert.SliceLen(t, deps, 3)
+	assert.Equal(t, deps[0].Type, "int")
+	assert.Equal(t, deps[1].Name, "a")
+	assert.SliceLen(t, deps[1].Dependencies, 1)
+	assert.Equal(t, deps[2].Name, "b")
+}
+
+func TestContainerGetDependencyGraphError(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, errors.New("error")
+	})
+	_, err := ctn.GetDependencyGraph(ctx)
+	var serviceErr *ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+	assert.ErrorEqual(t, err, "service string: error")
+}
+
+func TestGetDependencyGetAll(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name   string
+		getAll func(ctx context.Context, ctn Resolver) (map[string]string, error)
+	}{
+		{
+			name:   "GetAll",
+			getAll: GetAll[string],
+		},
+		{
+			name: "GetAllConcurrent",
+			getAll: func(ctx context.Context, ctn Resolver) (map[string]string, error) {
+				return GetAllConcurrent[string](ctx, ctn, 2)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctn := new(Container)
+			MustSet(ctn, "", func(ctx context.Context, ctn *Container) ([]string, Close, error) {
+				ss, err := tc.getAll(ctx, ctn)
+				return slices.Sorted(maps.Values(ss)), nil, err
+			})
+			for _, name := range []string{"a", "b", "c"} {
+				MustSetValue(ctn, name, name)
+			}
+			dep, err := GetDependency[[]string](ctx, ctn, "")
+			assert.NoError(t, err)
+			names := make([]string, len(dep.Dependencies))
+			for i, d := range dep.Dependencies {
+				names[i] = d.Name
+			}
+			slices.Sort(names)
+			assert.DeepEqual(t, names, []string{"a", "b", "c"})
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package di
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// SetWithDeps calls [Set] with [WithDependencies].
+func SetWithDeps[S any](ctn *Container, name string, deps []Key, b Builder[S], opts ...ServiceOption) error {
+	return Set(ctn, name, b, append([]ServiceOption{WithDependencies(deps...)}, opts...)...)
+}
+
+// MustSetWithDeps calls [SetWithDeps] and panics if there is an error.
+func MustSetWithDeps[S any](ctn *Container, name string, deps []Key, b Builder[S], opts ...ServiceOption) {
+	MustSet(ctn, name, b, append([]ServiceOption{WithDependencies(deps...)}, opts...)...)
+}
+
+// WithDependencies returns a [ServiceOption] that declares the dependencies of a service.
+//
+// The declared dependencies are checked by [Container.Validate] and [Container.DetectCycles] without calling the builders: they must be set, and must not form a cycle.
+// The services without declared dependencies are leaves of the declared graph.
+// See [KeyFor] to create the keys.
+//
+// With [WithStrictDependencies], the build fails if the [Builder] gets a service that is not declared.
+func WithDependencies(deps ...Key) ServiceOption {
+	return func(cfg *serviceConfig) {
+		cfg.deps = slices.Clone(deps)
+		cfg.depsDeclared = true
+	}
+}
+
+// WithStrictDependencies returns a [ContainerOption] that checks the declared dependencies while building the services, see [WithDependencies].
+//
+// If a [Builder] gets a service that is not declared, the build fails with [ErrUndeclaredDependency].
+// The services without declared dependencies are not checked.
+// It doesn't work with [WithoutDependencyTracking].
+// It is intended to be used for debugging and in tests.
+func WithStrictDependencies() ContainerOption {
+	return func(cfg *containerConfig) {
+		cfg.strictDependencies = true
+	}
+}
+
+// ErrUndeclaredDependency is returned when a [Builder] gets a service that is not declared, see [WithStrictDependencies].
+var ErrUndeclaredDependency = errors.New("undeclared dependency")
+
+// checkUndeclaredDependencies returns an error for each collected dependency that is not declared.
+func (sw *serviceWrapper) checkUndeclaredDependencies(deps []*Dependency) error {
+	var errs []error
+	for _, d := range deps {
+		if !slices.Contains(sw.deps, d.key) {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUndeclaredDependency, d.key))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkDeclaredDependencies checks the declared dependencies of the services, without building them.
+//
+// It returns the cycles, as in [Container.DetectCycles], and an error for each declared dependency that is not set.
+func (c *Container) checkDeclaredDependencies() (cycles [][]Key, errs []error) {
+	graph := make(map[Key][]Key)
+	c.allResolvable(func(key Key, sw *serviceWrapper) {
+		if sw.depsDeclared {
+			graph[key] = sw.deps
+		}
+	})
+	keys := slices.Collect(maps.Keys(graph))
+	sortKeys(keys)
+	for _, key := range keys {
+		for _, dep := range graph[key] {
+			_, _, err := c.getServiceWrapper(dep)
+			if err != nil {
+				errs = append(errs, c.wrapServiceError(fmt.Errorf("declared dependency %s: %w", dep, err), key))
+			}
+		}
+	}
+	return findDeclaredCycles(graph, keys), errs
+}
+
+// findDeclaredCycles returns all the elementary cycles of the graph, normalized and sorted.
+//
+// The keys must be sorted.
+// Each cycle is found from its smallest [Key], by only visiting the larger keys, so it is found once, even if it shares keys with other cycles.
+func findDeclaredCycles(graph map[Key][]Key, keys []Key) [][]Key {
+	ranks := make(map[Key]int, len(keys))
+	for i, key := range keys {
+		ranks[key] = i
+	}
+	var cycles [][]Key
+	seen := make(map[string]bool)
+	onPath := make(map[Key]bool)
+	var path []Key
+	var visit func(start, key Key)
+	visit = func(start, key Key) {
+		path = append(path, key)
+		onPath[key] = true
+		for _, dep := range graph[key] {
+			if dep == start {
+				cycle := append(slices.Clone(path), start)
+				id := cycleID(cycle)
+				if !seen[id] {
+					seen[id] = true
+					cycles = append(cycles, cycle)
+				}
+				continue
+			}
+			rank, ok := ranks[dep]
+			if ok && rank > ranks[start] && !onPath[dep] {
+				visit(start, dep)
+			}
+		}
+		onPath[key] = false
+		path = path[:len(path)-1]
+	}
+	for _, key := range keys {
+		visit(key, key)
+	}
+	slices.SortFunc(cycles, func(a, b []Key) int {
+		return cmp.Compare(cycleID(a), cycleID(b))
+	})
+	return cycles
+}
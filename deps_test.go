@@ -0,0 +1,111 @@
+package di
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestSetWithDeps(t *testing.T) {
+	ctx := context.Background()
+	ctn := NewContainer(WithStrictDependencies())
+	MustSetWithDeps(ctn, "a", []Key{KeyFor[string]("b")}, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return MustGet[string](ctx, ctn, "b"), nil, nil
+	})
+	MustSetValue(ctn, "b", "b")
+	err := ctn.Validate(ctx)
+	assert.NoError(t, err)
+	s := MustGet[string](ctx, ctn, "a")
+	assert.Equal(t, s, "b")
+}
+
+func TestSetWithDepsErrorAlreadySet(t *testing.T) {
+	ctn := new(Container)
+	MustSetValue(ctn, "", "")
+	err := SetWithDeps(ctn, "", nil, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, nil
+	})
+	assert.ErrorIs(t, err, ErrAlreadySet)
+}
+
+func TestWithStrictDependenciesErrorUndeclared(t *testing.T) {
+	ctx := context.Background()
+	ctn := NewContainer(WithStrictDependencies())
+	MustSetWithDeps(ctn, "a", nil, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return MustGet[string](ctx, ctn, "b"), nil, nil
+	})
+	MustSetValue(ctn, "b", "b")
+	MustSet(ctn, "c", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return MustGet[string](ctx, ctn, "b"), nil, nil
+	})
+	_, err := Get[string](ctx, ctn, "a")
+	assert.ErrorIs(t, err, ErrUndeclaredDependency)
+	assert.ErrorEqual(t, err, "service string(a): undeclared dependency: string(b)")
+	s := MustGet[string](ctx, ctn, "c")
+	assert.Equal(t, s, "b")
+}
+
+func TestValidateDeclaredDependenciesErrorNotSet(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	builderCalled := false
+	MustSetWithDeps(ctn, "a", []Key{KeyFor[string]("b")}, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		builderCalled = true
+		return "", nil, nil
+	})
+	err := ctn.Validate(ctx)
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.ErrorEqual(t, err, "service string(a): declared dependency string(b): not set")
+	assert.False(t, builderCalled)
+}
+
+func TestValidateDeclaredDependenciesErrorCycle(t *testing.T) {
+	ctx := context.Background()
+	ctn := newTestContainerDeclaredCycle()
+	err := ctn.Validate(ctx)
+	var cycleErr *CycleError
+	assert.ErrorAs(t, err, &cycleErr)
+	assert.DeepEqual(t, cycleErr.Keys, []Key{KeyFor[string]("a"), KeyFor[string]("b"), KeyFor[string]("a")})
+}
+
+func TestDetectCyclesDeclaredDependencies(t *testing.T) {
+	ctx := context.Background()
+	ctn := newTestContainerDeclaredCycle()
+	cycles, err := ctn.DetectCycles(ctx)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, cycles, [][]Key{
+		{KeyFor[string]("a"), KeyFor[string]("b"), KeyFor[string]("a")},
+	})
+}
+
+func TestDetectCyclesDeclaredDependenciesSharedKeys(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	a, b, c := KeyFor[string]("a"), KeyFor[string]("b"), KeyFor[string]("c")
+	bd := func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, nil
+	}
+	MustSetWithDeps(ctn, "a", []Key{b, c}, bd)
+	MustSetWithDeps(ctn, "b", []Key{c}, bd)
+	MustSetWithDeps(ctn, "c", []Key{a}, bd)
+	cycles, err := ctn.DetectCycles(ctx)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, cycles, [][]Key{
+		{a, b, c, a},
+		{a, c, a},
+	})
+}
+
+// newTestContainerDeclaredCycle returns a [Container] with a cycle in the declared dependencies, but not in the builders.
+func newTestContainerDeclaredCycle() *Container {
+	ctn := new(Container)
+	MustSetWithDeps(ctn, "a", []Key{KeyFor[string]("b")}, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, nil
+	})
+	MustSetWithDeps(ctn, "b", []Key{KeyFor[string]("a"), KeyFor[string]("c")}, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, nil
+	})
+	MustSetValue(ctn, "c", "")
+	return ctn
+}
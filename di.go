@@ -3,7 +3,10 @@ package di
 
 import (
 	"context"
+	"errors"
+	"maps"
 	"reflect"
+	"slices"
 )
 
 // Set sets a service to a [Container].
@@ -11,23 +14,214 @@ import (
 // Name is an optional identifier amongst the services of the same type.
 //
 // If the service is already set, it returns [ErrAlreadySet].
-func Set[S any](ctn *Container, name string, b Builder[S]) (err error) {
+func Set[S any](ctn *Container, name string, b Builder[S], opts ...ServiceOption) (err error) {
 	key := newKey[S](name)
 	typ := reflect.TypeFor[S]()
-	return ctn.set(key, typ, func(ctx context.Context, ctn *Container) (any, Close, error) {
+	return ctn.set(key, typ, newBuilder(b), opts)
+}
+
+func newBuilder[S any](b Builder[S]) builder {
+	return func(ctx context.Context, ctn *Container) (any, Close, error) {
 		return b(ctx, ctn)
-	})
+	}
 }
 
 // MustSet calls [Set] and panics if there is an error.
-func MustSet[S any](ctn *Container, name string, b Builder[S]) {
-	err := Set[S](ctn, name, b)
+func MustSet[S any](ctn *Container, name string, b Builder[S], opts ...ServiceOption) {
+	err := Set[S](ctn, name, b, opts...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SetBatch sets several services of the same type to a [Container].
+//
+// The key of the map is the name of the service.
+// The options are applied to all the services.
+//
+// It is all-or-nothing: if a service is already set, none is set, and it returns [ErrAlreadySet] wrapped in a [ServiceError] for this service.
+func SetBatch[S any](ctn *Container, builders map[string]Builder[S], opts ...ServiceOption) error {
+	typ := reflect.TypeFor[S]()
+	names := slices.Sorted(maps.Keys(builders))
+	sws := make([]*serviceWrapper, len(names))
+	for i, name := range names {
+		sws[i] = newServiceWrapper(newKey[S](name), typ, newBuilder(builders[name]), opts)
+	}
+	return ctn.setServiceWrappers(sws)
+}
+
+// MustSetBatch calls [SetBatch] and panics if there is an error.
+func MustSetBatch[S any](ctn *Container, builders map[string]Builder[S], opts ...ServiceOption) {
+	err := SetBatch(ctn, builders, opts...)
 	if err != nil {
 		panic(err)
 	}
 }
 
-// Get returns a service from a [Container].
+// SetFactory sets a factory service to a [Container].
+//
+// Contrary to [Set], the service is not cached: the [Builder] is called for each [Get], and returns a new instance.
+//
+// The [Close] functions returned by the [Builder] accumulate until [Container.Close] is called, which calls all of them.
+// The [Dependency] is recorded by the first build.
+func SetFactory[S any](ctn *Container, name string, b Builder[S], opts ...ServiceOption) error {
+	key := newKey[S](name)
+	typ := reflect.TypeFor[S]()
+	sw := newServiceWrapper(key, typ, newBuilder(b), opts)
+	sw.factory = true
+	return ctn.setServiceWrapper(sw)
+}
+
+// SetIfAbsent calls [Set] if the service is not already set, and returns true if it was set.
+//
+// It doesn't return [ErrAlreadySet], so a module can register its services several times.
+func SetIfAbsent[S any](ctn *Container, name string, b Builder[S], opts ...ServiceOption) (bool, error) {
+	key := newKey[S](name)
+	typ := reflect.TypeFor[S]()
+	return ctn.setIfAbsent(newServiceWrapper(key, typ, newBuilder(b), opts))
+}
+
+// MustSetIfAbsent calls [SetIfAbsent] and panics if there is an error.
+func MustSetIfAbsent[S any](ctn *Container, name string, b Builder[S], opts ...ServiceOption) bool {
+	ok, err := SetIfAbsent(ctn, name, b, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return ok
+}
+
+// MustSetFactory calls [SetFactory] and panics if there is an error.
+func MustSetFactory[S any](ctn *Container, name string, b Builder[S], opts ...ServiceOption) {
+	err := SetFactory(ctn, name, b, opts...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Replace replaces a service of a [Container].
+//
+// The previous service is replaced atomically, then it is closed if it is initialized.
+// The replacement is done even if closing fails, and the error is returned.
+//
+// If the service is not set, it returns [ErrNotSet].
+// If the [Container] is closed for good, it returns [ErrClosed].
+func Replace[S any](ctx context.Context, ctn *Container, name string, b Builder[S], opts ...ServiceOption) error {
+	key := newKey[S](name)
+	typ := reflect.TypeFor[S]()
+	return ctn.replace(ctx, key, typ, newBuilder(b), opts)
+}
+
+// MustReplace calls [Replace] and panics if there is an error.
+func MustReplace[S any](ctx context.Context, ctn *Container, name string, b Builder[S], opts ...ServiceOption) {
+	err := Replace(ctx, ctn, name, b, opts...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CloseService closes a service of a [Container].
+//
+// The service is still set, so the next [Get] builds it again.
+// It doesn't close the dependents of the service.
+// It returns nil if the service is not initialized.
+func CloseService[S any](ctx context.Context, ctn *Container, name string) error {
+	key := newKey[S](name)
+	return ctn.closeService(ctx, key)
+}
+
+// MustCloseService calls [CloseService] and panics if there is an error.
+func MustCloseService[S any](ctx context.Context, ctn *Container, name string) {
+	err := CloseService[S](ctx, ctn, name)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SetPtr calls [Set] for a pointer service.
+//
+// If the [Builder] returns a nil pointer without error, the build fails with [ErrNilService].
+func SetPtr[T any](ctn *Container, name string, b Builder[*T], opts ...ServiceOption) error {
+	return Set(ctn, name, newPtrBuilder(b), opts...)
+}
+
+// MustSetPtr calls [SetPtr] and panics if there is an error.
+func MustSetPtr[T any](ctn *Container, name string, b Builder[*T], opts ...ServiceOption) {
+	MustSet(ctn, name, newPtrBuilder(b), opts...)
+}
+
+func newPtrBuilder[T any](b Builder[*T]) Builder[*T] {
+	return func(ctx context.Context, ctn *Container) (*T, Close, error) {
+		s, cl, err := b(ctx, ctn)
+		if err != nil {
+			return nil, cl, err
+		}
+		if s == nil {
+			return nil, cl, ErrNilService
+		}
+		return s, cl, nil
+	}
+}
+
+// SetValue sets an already built service value to a [Container].
+//
+// The service has no dependencies and no [Close] function.
+func SetValue[S any](ctn *Container, name string, value S) error {
+	return Set(ctn, name, newValueBuilder(value))
+}
+
+// MustSetValue calls [SetValue] and panics if there is an error.
+func MustSetValue[S any](ctn *Container, name string, value S) {
+	MustSet(ctn, name, newValueBuilder(value))
+}
+
+func newValueBuilder[S any](value S) Builder[S] {
+	return func(ctx context.Context, ctn *Container) (S, Close, error) {
+		return value, nil, nil
+	}
+}
+
+// SetSimple calls [Set] with a [SimpleBuilder].
+func SetSimple[S any](ctn *Container, name string, b SimpleBuilder[S], opts ...ServiceOption) error {
+	return Set(ctn, name, newSimpleBuilder(b), opts...)
+}
+
+// MustSetSimple calls [SetSimple] and panics if there is an error.
+func MustSetSimple[S any](ctn *Container, name string, b SimpleBuilder[S], opts ...ServiceOption) {
+	MustSet(ctn, name, newSimpleBuilder(b), opts...)
+}
+
+// SimpleBuilder is a [Builder] without [Close] function.
+type SimpleBuilder[S any] func(ctx context.Context, ctn *Container) (S, error)
+
+func newSimpleBuilder[S any](b SimpleBuilder[S]) Builder[S] {
+	return func(ctx context.Context, ctn *Container) (S, Close, error) {
+		s, err := b(ctx, ctn)
+		return s, nil, err
+	}
+}
+
+// SetWithKey calls [Set] with a [KeyBuilder].
+func SetWithKey[S any](ctn *Container, name string, b KeyBuilder[S], opts ...ServiceOption) error {
+	return Set(ctn, name, newKeyBuilder(newKey[S](name), b), opts...)
+}
+
+// MustSetWithKey calls [SetWithKey] and panics if there is an error.
+func MustSetWithKey[S any](ctn *Container, name string, b KeyBuilder[S], opts ...ServiceOption) {
+	MustSet(ctn, name, newKeyBuilder(newKey[S](name), b), opts...)
+}
+
+// KeyBuilder is a [Builder] that receives the [Key] of the service.
+//
+// It allows to use the same function for several services, e.g. to label metrics with the name of the service.
+type KeyBuilder[S any] func(ctx context.Context, ctn *Container, key Key) (S, Close, error)
+
+func newKeyBuilder[S any](key Key, b KeyBuilder[S]) Builder[S] {
+	return func(ctx context.Context, ctn *Container) (S, Close, error) {
+		return b(ctx, ctn, key)
+	}
+}
+
+// Get returns a service from a [Resolver].
 //
 // Name is an optional identifier amongst the services of the same type.
 //
@@ -35,18 +229,49 @@ func MustSet[S any](ctn *Container, name string, b Builder[S]) {
 //
 // If the service is not yet initialized, it calls its [Builder].
 // If the [Builder] fails, it returns the error.
-func Get[S any](ctx context.Context, ctn *Container, name string) (s S, err error) {
+func Get[S any](ctx context.Context, ctn Resolver, name string) (s S, err error) {
 	key := newKey[S](name)
-	v, err := ctn.get(ctx, key)
+	return get[S](ctx, ctn, key)
+}
+
+func get[S any](ctx context.Context, ctn Resolver, key Key) (s S, err error) {
+	v, err := ctn.container().get(ctx, key)
 	if err != nil {
 		return s, err
 	}
-	s = v.(S) //nolint:forcetypeassert // We know the type.
+	s, err = assertServiceType[S](v)
+	if err != nil {
+		return s, ctn.container().wrapServiceError(err, key)
+	}
 	return s, nil
 }
 
+// assertServiceType returns the service as S, or a [TypeMismatchError].
+//
+// A nil service is returned as the zero value, e.g. for a nil interface.
+func assertServiceType[S any](v any) (S, error) {
+	s, ok := v.(S)
+	if !ok && v != nil {
+		return s, &TypeMismatchError{
+			Expected: reflect.TypeFor[S](),
+			Actual:   reflect.TypeOf(v),
+		}
+	}
+	return s, nil
+}
+
+// IsInitialized returns true if a service of a [Resolver] is initialized.
+//
+// It doesn't initialize the service.
+// It always returns false for a factory service.
+// If the service is not found, it returns [ErrNotSet].
+func IsInitialized[S any](ctn Resolver, name string) (bool, error) {
+	key := newKey[S](name)
+	return ctn.container().isInitialized(key)
+}
+
 // MustGet calls [Get] and panics if there is an error.
-func MustGet[S any](ctx context.Context, ctn *Container, name string) S {
+func MustGet[S any](ctx context.Context, ctn Resolver, name string) S {
 	s, err := Get[S](ctx, ctn, name)
 	if err != nil {
 		panic(err)
@@ -54,22 +279,114 @@ func MustGet[S any](ctx context.Context, ctn *Container, name string) S {
 	return s
 }
 
-// GetAll returns all services of a type from a [Container].
+// GetWithClose returns a service from a [Container], and its [Close] function, that the caller becomes responsible for.
 //
-// The key of the map is the name of the service.
-func GetAll[S any](ctx context.Context, ctn *Container) (map[string]S, error) {
-	var names []string
-	typ := reflect.TypeFor[S]()
-	ctn.all(func(key Key, sw *serviceWrapper) {
-		if sw.typ == typ {
-			names = append(names, key.Name)
+// It requires a [Container] rather than a [Resolver], so a read-only view (see [Container.ReadOnly]) can't detach the [Close] function of a shared service.
+//
+// The [Close] function is detached from the [Container]: [Container.Close] doesn't call it anymore.
+// The service is still cached, so the other [Get] calls return the same instance, even after the caller has closed it.
+// The next calls return a nil [Close] function, until the service is built again, so the service is not closed twice.
+// The caller must not close the service while it is used by other services.
+//
+// For a factory service (see [SetFactory]), the instance is new, and its [Close] function is not kept by the [Container].
+func GetWithClose[S any](ctx context.Context, ctn *Container, name string) (s S, cl Close, err error) {
+	key := newKey[S](name)
+	v, cl, err := ctn.getWithClose(ctx, key)
+	if err != nil {
+		return s, nil, err
+	}
+	s, err = assertServiceType[S](v)
+	if err != nil {
+		return s, cl, ctn.wrapServiceError(err, key)
+	}
+	return s, cl, nil
+}
+
+// MustGetWithClose calls [GetWithClose] and panics if there is an error.
+func MustGetWithClose[S any](ctx context.Context, ctn *Container, name string) (S, Close) {
+	s, cl, err := GetWithClose[S](ctx, ctn, name)
+	if err != nil {
+		panic(err)
+	}
+	return s, cl
+}
+
+// GetFunc returns a function that gets a service from a [Resolver].
+//
+// The service is not cached by the function, contrary to [Provider].
+// It can be passed to code that is not aware of the [Container].
+func GetFunc[S any](ctn Resolver, name string) func(ctx context.Context) (S, error) {
+	key := newKey[S](name)
+	return func(ctx context.Context) (S, error) {
+		return get[S](ctx, ctn, key)
+	}
+}
+
+// GetOrBuild returns a service from a [Container], or sets it with the [Builder] if it is not set.
+//
+// If several goroutines call it concurrently, only one [Builder] is set, and the service is built once.
+func GetOrBuild[S any](ctx context.Context, ctn *Container, name string, b Builder[S]) (s S, err error) {
+	key := newKey[S](name)
+	_, _, err = ctn.getServiceWrapper(key)
+	if errors.Is(err, ErrNotSet) {
+		_, err = ctn.setIfAbsent(newServiceWrapper(key, reflect.TypeFor[S](), newBuilder(b), nil))
+		if err != nil {
+			return s, err
 		}
-	})
+	}
+	return get[S](ctx, ctn, key)
+}
+
+// MustGetOrBuild calls [GetOrBuild] and panics if there is an error.
+func MustGetOrBuild[S any](ctx context.Context, ctn *Container, name string, b Builder[S]) S {
+	s, err := GetOrBuild(ctx, ctn, name, b)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// GetOrSet returns a service from a [Container], and sets it with the [Builder] before if it is not set in the [Container].
+//
+// The check and the set are atomic, so concurrent calls set a single [Builder], and the service is built once.
+// Contrary to [GetOrBuild], a service of the parent [Container] is shadowed.
+func GetOrSet[S any](ctx context.Context, ctn *Container, name string, b Builder[S], opts ...ServiceOption) (S, error) {
+	key := newKey[S](name)
+	_, err := ctn.setIfAbsent(newServiceWrapper(key, reflect.TypeFor[S](), newBuilder(b), opts))
+	if err != nil {
+		var zero S
+		return zero, err
+	}
+	return get[S](ctx, ctn, key)
+}
+
+// MustGetOrSet calls [GetOrSet] and panics if there is an error.
+func MustGetOrSet[S any](ctx context.Context, ctn *Container, name string, b Builder[S], opts ...ServiceOption) S {
+	s, err := GetOrSet(ctx, ctn, name, b, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// GetAll returns all services of a type from a [Resolver].
+//
+// The key of the map is the name of the service.
+// If the [context.Context] is canceled, it stops and returns the context error.
+// If it is called from a [Builder], the services are recorded as dependencies, see [GetDependency].
+// Versioned services are not included.
+// The services of the parents are included, see [Container.NewChild].
+func GetAll[S any](ctx context.Context, ctn Resolver) (map[string]S, error) {
+	names := getNames[S](ctn)
 	var ss map[string]S
 	if len(names) > 0 {
 		ss = make(map[string]S, len(names))
 	}
 	for _, name := range names {
+		err := ctx.Err()
+		if err != nil {
+			return nil, err //nolint:wrapcheck // We don't need to wrap.
+		}
 		s, err := Get[S](ctx, ctn, name)
 		if err != nil {
 			return nil, err
@@ -79,6 +396,161 @@ func GetAll[S any](ctx context.Context, ctn *Container) (map[string]S, error) {
 	return ss, nil
 }
 
+// MustGetAll calls [GetAll] and panics if there is an error.
+func MustGetAll[S any](ctx context.Context, ctn Resolver) map[string]S {
+	ss, err := GetAll[S](ctx, ctn)
+	if err != nil {
+		panic(err)
+	}
+	return ss
+}
+
+// GetAllPartial is like [GetAll], but it doesn't stop at the first error.
+//
+// It returns the services that were successfully built, and the errors of the others joined.
+func GetAllPartial[S any](ctx context.Context, ctn Resolver) (map[string]S, error) {
+	names := GetNames[S](ctn)
+	var ss map[string]S
+	if len(names) > 0 {
+		ss = make(map[string]S, len(names))
+	}
+	var errs []error
+	for _, name := range names {
+		err := ctx.Err()
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+		s, err := Get[S](ctx, ctn, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ss[name] = s
+	}
+	return ss, errors.Join(errs...)
+}
+
+// GetAllOrdered is like [GetAll], but it returns the services in a slice sorted by name.
+func GetAllOrdered[S any](ctx context.Context, ctn Resolver) ([]NamedService[S], error) {
+	return getAllNamed[S](ctx, ctn, GetNames[S](ctn))
+}
+
+// GetAllInRegistrationOrder is like [GetAll], but it returns the services in a slice, in the order they were set.
+//
+// The services of the parents are first.
+// A service of a child that shadows a service of a parent has the position of the parent's service.
+func GetAllInRegistrationOrder[S any](ctx context.Context, ctn Resolver) ([]NamedService[S], error) {
+	return getAllNamed[S](ctx, ctn, getNamesInRegistrationOrder[S](ctn))
+}
+
+func getAllNamed[S any](ctx context.Context, ctn Resolver, names []string) ([]NamedService[S], error) {
+	nss := make([]NamedService[S], len(names))
+	for i, name := range names {
+		err := ctx.Err()
+		if err != nil {
+			return nil, err //nolint:wrapcheck // We don't need to wrap.
+		}
+		s, err := Get[S](ctx, ctn, name)
+		if err != nil {
+			return nil, err
+		}
+		nss[i] = NamedService[S]{
+			Name:    name,
+			Service: s,
+		}
+	}
+	return nss, nil
+}
+
+// NamedService is a service with its name.
+//
+// It is returned by [GetAllOrdered] and [GetAllInRegistrationOrder].
+type NamedService[S any] struct {
+	Name    string
+	Service S
+}
+
+// GetAllConcurrent is like [GetAll], but it gets the services concurrently, with at most maxWorkers goroutines.
+//
+// The dependencies shared by several services are still built once.
+// See [Container.InitializeParallel] for the details of the concurrent build.
+//
+// The errors of all services are joined.
+// If a service fails, the other builds are not canceled, and no map is returned: it is only returned if all builds succeed.
+// The cycle detection works, because each goroutine gets the service with its own build chain.
+func GetAllConcurrent[S any](ctx context.Context, ctn Resolver, maxWorkers int) (map[string]S, error) {
+	names := GetNames[S](ctn) // Sorted, so the errors are returned in a deterministic order.
+	keys := make([]Key, len(names))
+	for i, name := range names {
+		keys[i] = newKey[S](name)
+	}
+	vs, err := ctn.container().getParallel(ctx, keys, maxWorkers)
+	if err != nil {
+		return nil, err
+	}
+	var ss map[string]S
+	if len(names) > 0 {
+		ss = make(map[string]S, len(names))
+	}
+	for i, name := range names {
+		ss[name], err = assertServiceType[S](vs[i])
+		if err != nil {
+			return nil, ctn.container().wrapServiceError(err, keys[i])
+		}
+	}
+	return ss, nil
+}
+
+// MustGetAllConcurrent calls [GetAllConcurrent] and panics if there is an error.
+func MustGetAllConcurrent[S any](ctx context.Context, ctn Resolver, maxWorkers int) map[string]S {
+	ss, err := GetAllConcurrent[S](ctx, ctn, maxWorkers)
+	if err != nil {
+		panic(err)
+	}
+	return ss
+}
+
+// GetNames returns the names of all services of a type from a [Resolver], sorted.
+//
+// It doesn't initialize the services.
+// Versioned services are not included.
+func GetNames[S any](ctn Resolver) []string {
+	names := getNames[S](ctn)
+	slices.Sort(names)
+	return names
+}
+
+func getNamesInRegistrationOrder[S any](ctn Resolver) []string {
+	var ctns []*Container
+	for c := ctn.container(); c != nil; c = c.parent {
+		ctns = append(ctns, c)
+	}
+	var names []string
+	seen := make(map[string]bool)
+	typ := reflect.TypeFor[S]()
+	for _, c := range slices.Backward(ctns) {
+		c.services.allOrdered(func(key Key, sw *serviceWrapper) {
+			if sw.typ == typ && key.Version == 0 && !seen[key.Name] {
+				seen[key.Name] = true
+				names = append(names, key.Name)
+			}
+		})
+	}
+	return names
+}
+
+func getNames[S any](ctn Resolver) []string {
+	var names []string
+	typ := reflect.TypeFor[S]()
+	ctn.container().allResolvable(func(key Key, sw *serviceWrapper) {
+		if sw.typ == typ && key.Version == 0 {
+			names = append(names, key.Name)
+		}
+	})
+	return names
+}
+
 // Builder builds a service.
 //
 // The [Close] function allows to close the service.
@@ -90,5 +562,8 @@ func GetAll[S any](ctx context.Context, ctn *Container) (map[string]S, error) {
 // If it calls [Get], it must provide the same [context.Context].
 type Builder[S any] func(ctx context.Context, ctn *Container) (S, Close, error)
 
+// ServiceOption represents an option for a service.
+type ServiceOption func(cfg *serviceConfig)
+
 // Close closes a service.
 type Close func(ctx context.Context) error
@@ -17,6 +17,19 @@ func Example() {
 	// myService.myMethod
 }
 
+func ExampleMustGetAll() {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "a", "value a")
+	MustSetValue(ctn, "b", "value b")
+	ss := MustGetAll[string](ctx, ctn)
+	fmt.Println(ss["a"])
+	fmt.Println(ss["b"])
+	// Output:
+	// value a
+	// value b
+}
+
 type myService struct{}
 
 func (s *myService) myMethod() {
@@ -3,6 +3,10 @@ package di
 import (
 	"context"
 	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/pierrre/assert"
@@ -55,6 +59,231 @@ func TestMustSetPanicAlreadySet(t *testing.T) {
 	})
 }
 
+func TestSetBatch(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	err := SetBatch(ctn, map[string]Builder[string]{
+		"a": func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "a", nil, nil
+		},
+		"b": func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "b", nil, nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, MustGet[string](ctx, ctn, "a"), "a")
+	assert.Equal(t, MustGet[string](ctx, ctn, "b"), "b")
+}
+
+func TestSetBatchErrorAlreadySet(t *testing.T) {
+	ctn := new(Container)
+	MustSetValue(ctn, "b", "b")
+	err := SetBatch(ctn, map[string]Builder[string]{
+		"a": func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "a", nil, nil
+		},
+		"b": func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "b", nil, nil
+		},
+	})
+	assert.ErrorIs(t, err, ErrAlreadySet)
+	var serviceErr *ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+	assert.Equal(t, serviceErr.Key, newKey[string]("b"))
+	assert.Equal(t, ctn.Len(), 1)
+}
+
+func TestSetBatchErrorClosed(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	err := ctn.Shutdown(ctx)
+	assert.NoError(t, err)
+	err = SetBatch(ctn, map[string]Builder[string]{
+		"a": func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "a", nil, nil
+		},
+	})
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestMustSetBatchPanic(t *testing.T) {
+	ctn := new(Container)
+	MustSetValue(ctn, "a", "a")
+	assert.Panics(t, func() {
+		MustSetBatch(ctn, map[string]Builder[string]{
+			"a": func(ctx context.Context, ctn *Container) (string, Close, error) {
+				return "a", nil, nil
+			},
+		})
+	})
+}
+
+func TestSetFactory(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	builderCalled := 0
+	closeCalled := 0
+	err := SetFactory(ctn, "", func(ctx context.Context, ctn *Container) (*int, Close, error) {
+		builderCalled++
+		i := builderCalled
+		return &i, func(ctx context.Context) error {
+			closeCalled++
+			return nil
+		}, nil
+	})
+	assert.NoError(t, err)
+	count := 3
+	for i := range count {
+		s := MustGet[*int](ctx, ctn, "")
+		assert.Equal(t, *s, i+1)
+	}
+	assert.Equal(t, builderCalled, count)
+	dep, err := GetDependency[*int](ctx, ctn, "")
+	assert.NoError(t, err)
+	assert.NotZero(t, dep)
+	assert.Equal(t, builderCalled, count)
+	err = ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closeCalled, count)
+}
+
+func TestSetFactoryErrorBuilder(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetFactory(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, errors.New("error")
+	})
+	_, err := Get[string](ctx, ctn, "")
+	assert.ErrorEqual(t, err, "service string: error")
+	_, err = GetDependency[string](ctx, ctn, "")
+	assert.ErrorEqual(t, err, "service string: error")
+}
+
+func TestReplace(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closeCalled := 0
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "a", func(ctx context.Context) error {
+			closeCalled++
+			return nil
+		}, nil
+	})
+	s := MustGet[string](ctx, ctn, "")
+	assert.Equal(t, s, "a")
+	err := Replace(ctx, ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "b", nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, closeCalled, 1)
+	s = MustGet[string](ctx, ctn, "")
+	assert.Equal(t, s, "b")
+}
+
+func TestReplaceErrorNotSet(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	err := Replace(ctx, ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, nil
+	})
+	var serviceErr *ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+	assert.Equal(t, serviceErr.Key, newKey[string](""))
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.Panics(t, func() {
+		MustReplace(ctx, ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "", nil, nil
+		})
+	})
+}
+
+func TestReplaceErrorClosed(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "", "a")
+	err := ctn.Shutdown(ctx)
+	assert.NoError(t, err)
+	err = Replace(ctx, ctn, "", newValueBuilder("b"))
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestReplaceConcurrentGet(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var built, closed atomic.Int64
+	b := func(ctx context.Context, ctn *Container) (int, Close, error) {
+		built.Add(1)
+		return 1, func(ctx context.Context) error {
+			closed.Add(1)
+			return nil
+		}, nil
+	}
+	MustSet(ctn, "", b)
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				MustGet[int](ctx, ctn, "")
+			}
+		}()
+	}
+	for range 100 {
+		MustReplace(ctx, ctn, "", b)
+	}
+	wg.Wait()
+	err := ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closed.Load(), built.Load())
+}
+
+func TestSetPtr(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	err := SetPtr(ctn, "", func(ctx context.Context, ctn *Container) (*myService, Close, error) {
+		return &myService{}, nil, nil
+	})
+	assert.NoError(t, err)
+	s, err := Get[*myService](ctx, ctn, "")
+	assert.NoError(t, err)
+	assert.NotZero(t, s)
+}
+
+func TestSetPtrErrorNil(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetPtr(ctn, "", func(ctx context.Context, ctn *Container) (*myService, Close, error) {
+		return nil, nil, nil
+	})
+	_, err := Get[*myService](ctx, ctn, "")
+	assert.ErrorIs(t, err, ErrNilService)
+	assert.ErrorEqual(t, err, "service *github.com/pierrre/di.myService: nil service")
+}
+
+func TestSetValue(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	err := SetValue(ctn, "", "test")
+	assert.NoError(t, err)
+	s, err := Get[string](ctx, ctn, "")
+	assert.NoError(t, err)
+	assert.Equal(t, s, "test")
+	dep, err := GetDependency[string](ctx, ctn, "")
+	assert.NoError(t, err)
+	assert.SliceEmpty(t, dep.Dependencies)
+}
+
+func TestSetValueErrorAlreadySet(t *testing.T) {
+	ctn := new(Container)
+	MustSetValue(ctn, "", "test")
+	err := SetValue(ctn, "", "test")
+	assert.ErrorIs(t, err, ErrAlreadySet)
+	assert.Panics(t, func() {
+		MustSetValue(ctn, "", "test")
+	})
+}
+
 func TestGetErrorNotSet(t *testing.T) {
 	ctx := context.Background()
 	ctn := new(Container)
@@ -97,6 +326,85 @@ func TestGetErrorPanic(t *testing.T) {
 	assert.ErrorEqual(t, err, "service string: panic: error")
 }
 
+func TestSetSimple(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	err := SetSimple(ctn, "", func(ctx context.Context, ctn *Container) (string, error) {
+		return "test", nil
+	})
+	assert.NoError(t, err)
+	MustSetSimple(ctn, "error", func(ctx context.Context, ctn *Container) (string, error) {
+		return "", errors.New("error")
+	})
+	s := MustGet[string](ctx, ctn, "")
+	assert.Equal(t, s, "test")
+	_, err = Get[string](ctx, ctn, "error")
+	assert.ErrorEqual(t, err, "service string(error): error")
+	assert.Panics(t, func() {
+		MustSetSimple(ctn, "", func(ctx context.Context, ctn *Container) (string, error) {
+			return "", nil
+		})
+	})
+}
+
+func TestSetWithKey(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	b := func(ctx context.Context, ctn *Container, key Key) (string, Close, error) {
+		if key.Name == "b" {
+			MustGet[string](ctx, ctn, "a")
+		}
+		return key.String(), nil, nil
+	}
+	err := SetWithKey(ctn, "a", b)
+	assert.NoError(t, err)
+	MustSetWithKey(ctn, "b", b)
+	assert.Equal(t, MustGet[string](ctx, ctn, "a"), "string(a)")
+	assert.Equal(t, MustGet[string](ctx, ctn, "b"), "string(b)")
+	dep, err := GetDependency[string](ctx, ctn, "b")
+	assert.NoError(t, err)
+	assert.SliceLen(t, dep.Dependencies, 1)
+	assert.Panics(t, func() {
+		MustSetWithKey(ctn, "a", b)
+	})
+}
+
+func TestGetErrorTypeMismatch(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	key := newKey[string]("")
+	err := ctn.set(key, reflect.TypeFor[string](), newBuilder(func(ctx context.Context, ctn *Container) (int, Close, error) {
+		return 1, nil, nil
+	}), nil)
+	assert.NoError(t, err)
+	_, err = Get[string](ctx, ctn, "")
+	var serviceErr *ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+	assert.Equal(t, serviceErr.Key, key)
+	var typeErr *TypeMismatchError
+	assert.ErrorAs(t, err, &typeErr)
+	assert.Equal(t, typeErr.Expected, reflect.TypeFor[string]())
+	assert.Equal(t, typeErr.Actual, reflect.TypeFor[int]())
+	assert.ErrorEqual(t, err, "service string: type mismatch: expected string, got int")
+	_, err = GetAllConcurrent[string](ctx, ctn, 1)
+	assert.ErrorAs(t, err, &typeErr)
+}
+
+func TestGetErrorPanicStack(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		panic("test")
+	})
+	_, err := Get[string](ctx, ctn, "")
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr)
+	assert.SliceNotEmpty(t, panicErr.Stack)
+	s := panicErr.ErrorStack()
+	assert.StringHasPrefix(t, s, "panic: test\n")
+	assert.StringContains(t, s, "TestGetErrorPanicStack")
+}
+
 func TestGetErrorPanicChain(t *testing.T) {
 	ctx := context.Background()
 	ctn := new(Container)
@@ -113,6 +421,17 @@ func TestGetErrorPanicChain(t *testing.T) {
 	})
 	_, err := Get[string](ctx, ctn, "a")
 	assert.ErrorEqual(t, err, "service string(a): panic: service string(b): panic: service string(c): panic: test")
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr)
+	assert.DeepEqual(t, panicErr.Chain, []Key{newKey[string]("a")})
+	for {
+		var next *PanicError
+		if !errors.As(panicErr.Unwrap(), &next) {
+			break
+		}
+		panicErr = next
+	}
+	assert.DeepEqual(t, panicErr.Chain, []Key{newKey[string]("c"), newKey[string]("b"), newKey[string]("a")})
 }
 
 func TestGetErrorCycle(t *testing.T) {
@@ -120,7 +439,27 @@ func TestGetErrorCycle(t *testing.T) {
 	ctn := newTestContainerCycle()
 	_, err := Get[string](ctx, ctn, "a")
 	assert.ErrorIs(t, err, ErrCycle)
-	assert.ErrorEqual(t, err, "service string(a): service string(b): service string(c): service string(a): cycle")
+	assert.ErrorEqual(t, err, "service string(a): service string(b): service string(c): service string(a): cycle: string(a) -> string(b) -> string(c) -> string(a)")
+	var cycleErr *CycleError
+	assert.ErrorAs(t, err, &cycleErr)
+	assert.DeepEqual(t, cycleErr.Keys, []Key{newKey[string]("a"), newKey[string]("b"), newKey[string]("c"), newKey[string]("a")})
+}
+
+func TestGetErrorCycleSelf(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		_, err := Get[string](ctx, ctn, "a")
+		return "", nil, err
+	})
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		_, err := Get[string](ctx, ctn, "a")
+		return "", nil, err
+	})
+	_, err := Get[string](ctx, ctn, "b")
+	var cycleErr *CycleError
+	assert.ErrorAs(t, err, &cycleErr)
+	assert.DeepEqual(t, cycleErr.Keys, []Key{newKey[string]("a"), newKey[string]("a")})
 }
 
 func newTestContainerCycle() *Container {
@@ -189,6 +528,117 @@ func TestMustGetPanic(t *testing.T) {
 	})
 }
 
+func TestGetWithClose(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closeCalled := 0
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "test", func(ctx context.Context) error {
+			closeCalled++
+			return nil
+		}, nil
+	})
+	s, cl := MustGetWithClose[string](ctx, ctn, "")
+	assert.Equal(t, s, "test")
+	assert.True(t, cl != nil)
+	s, cl2 := MustGetWithClose[string](ctx, ctn, "")
+	assert.Equal(t, s, "test")
+	assert.True(t, cl2 == nil)
+	err := ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closeCalled, 0)
+	err = cl(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closeCalled, 1)
+}
+
+func TestGetWithCloseFactory(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closeCalled := 0
+	MustSetFactory(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "test", func(ctx context.Context) error {
+			closeCalled++
+			return nil
+		}, nil
+	})
+	_, cl := MustGetWithClose[string](ctx, ctn, "")
+	assert.True(t, cl != nil)
+	MustGet[string](ctx, ctn, "")
+	err := ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closeCalled, 1)
+	err = cl(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closeCalled, 2)
+}
+
+func TestGetWithCloseErrorNotSet(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	_, _, err := GetWithClose[string](ctx, ctn, "")
+	assert.ErrorIs(t, err, ErrNotSet)
+}
+
+func TestGetWithCloseErrorTypeMismatch(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetByType(ctn, reflect.TypeFor[string](), "", func(ctx context.Context, ctn *Container) (any, Close, error) {
+		return 1, nil, nil
+	})
+	_, _, err := GetWithClose[string](ctx, ctn, "")
+	var tmErr *TypeMismatchError
+	assert.ErrorAs(t, err, &tmErr)
+}
+
+func TestMustGetWithClosePanic(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	assert.Panics(t, func() {
+		MustGetWithClose[string](ctx, ctn, "")
+	})
+}
+
+func TestGetFunc(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	builderCalled := 0
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		builderCalled++
+		return "test", nil, nil
+	})
+	f := GetFunc[string](ctn, "")
+	assert.Equal(t, builderCalled, 0)
+	s, err := f(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, s, "test")
+	s, err = f(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, s, "test")
+	assert.Equal(t, builderCalled, 1)
+}
+
+func TestGetFuncError(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	f := GetFunc[string](ctn, "")
+	_, err := f(ctx)
+	assert.ErrorIs(t, err, ErrNotSet)
+}
+
+func TestGetFuncAllocs(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "", "test")
+	f := GetFunc[string](ctn, "")
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = Get[string](ctx, ctn, "")
+	})
+	assert.AllocsPerRun(t, 100, func() {
+		_, _ = f(ctx)
+	}, allocs)
+}
+
 func BenchmarkGet(b *testing.B) {
 	ctx := context.Background()
 	ctn := new(Container)
@@ -201,6 +651,165 @@ func BenchmarkGet(b *testing.B) {
 	}
 }
 
+func BenchmarkGetParallel(b *testing.B) {
+	ctx := context.Background()
+	ctn := new(Container)
+	const count = 100
+	for i := range count {
+		MustSetValue(ctn, strconv.Itoa(i), "")
+	}
+	var next atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		name := strconv.Itoa(int(next.Add(1) % count)) // Each goroutine gets a different service, so they don't wait for the same service lock.
+		for pb.Next() {
+			_, _ = Get[string](ctx, ctn, name)
+		}
+	})
+}
+
+func TestCloseService(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	aCalls, aCloses := 0, 0
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		aCalls++
+		return "a", func(ctx context.Context) error {
+			aCloses++
+			return nil
+		}, nil
+	})
+	MustSetValue(ctn, "b", "b")
+	MustGet[string](ctx, ctn, "a")
+	MustGet[string](ctx, ctn, "b")
+	err := CloseService[string](ctx, ctn, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, aCloses, 1)
+	assert.DeepEqual(t, ctn.InitializationOrder(), []Key{newKey[string]("b")})
+	MustGet[string](ctx, ctn, "a")
+	assert.Equal(t, aCalls, 2)
+	MustCloseService[string](ctx, ctn, "a")
+	MustCloseService[string](ctx, ctn, "a")
+	assert.Equal(t, aCloses, 2)
+}
+
+func TestCloseServiceErrorNotSet(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	err := CloseService[string](ctx, ctn, "")
+	assert.ErrorIs(t, err, ErrNotSet)
+	var serviceErr *ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+	assert.Panics(t, func() {
+		MustCloseService[string](ctx, ctn, "")
+	})
+}
+
+func TestGetOrBuild(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var builderCallCount atomic.Int64
+	b := func(ctx context.Context, ctn *Container) (string, Close, error) {
+		builderCallCount.Add(1)
+		return "test", nil, nil
+	}
+	wg := new(sync.WaitGroup)
+	for range 10 {
+		goroutine.WaitGroup(ctx, wg, func(ctx context.Context) {
+			s, err := GetOrBuild(ctx, ctn, "", b)
+			assert.NoError(t, err)
+			assert.Equal(t, s, "test")
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, builderCallCount.Load(), 1)
+	s := MustGetOrBuild(ctx, ctn, "", b)
+	assert.Equal(t, s, "test")
+	assert.Equal(t, builderCallCount.Load(), 1)
+}
+
+func TestGetOrBuildParent(t *testing.T) {
+	ctx := context.Background()
+	parent := new(Container)
+	MustSetValue(parent, "", "parent")
+	ctn := parent.NewChild()
+	s, err := GetOrBuild(ctx, ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "child", nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, s, "parent")
+	assert.SliceEmpty(t, ctn.Keys())
+}
+
+func TestMustGetOrBuildPanic(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	assert.Panics(t, func() {
+		MustGetOrBuild(ctx, ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "", nil, errors.New("error")
+		})
+	})
+}
+
+func TestSetIfAbsent(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	ok, err := SetIfAbsent(ctn, "", newValueBuilder("a"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = SetIfAbsent(ctn, "", newValueBuilder("b"))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok = MustSetIfAbsent(ctn, "", newValueBuilder("c"))
+	assert.False(t, ok)
+	s := MustGet[string](ctx, ctn, "")
+	assert.Equal(t, s, "a")
+}
+
+func TestGetOrSet(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var builderCallCount atomic.Int64
+	b := func(ctx context.Context, ctn *Container) (string, Close, error) {
+		builderCallCount.Add(1)
+		return "test", nil, nil
+	}
+	wg := new(sync.WaitGroup)
+	for range 10 {
+		goroutine.WaitGroup(ctx, wg, func(ctx context.Context) {
+			s, err := GetOrSet(ctx, ctn, "", b)
+			assert.NoError(t, err)
+			assert.Equal(t, s, "test")
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, builderCallCount.Load(), 1)
+	s := MustGetOrSet(ctx, ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "other", nil, nil
+	})
+	assert.Equal(t, s, "test")
+}
+
+func TestGetOrSetParent(t *testing.T) {
+	ctx := context.Background()
+	parent := new(Container)
+	MustSetValue(parent, "", "parent")
+	ctn := parent.NewChild()
+	s, err := GetOrSet(ctx, ctn, "", newValueBuilder("child"))
+	assert.NoError(t, err)
+	assert.Equal(t, s, "child")
+}
+
+func TestMustGetOrSetPanic(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	assert.Panics(t, func() {
+		MustGetOrSet(ctx, ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "", nil, errors.New("error")
+		})
+	})
+}
+
 func TestGetAll(t *testing.T) {
 	ctx := context.Background()
 	ctn := new(Container)
@@ -215,6 +824,26 @@ func TestGetAll(t *testing.T) {
 	assert.MapLen(t, ss, 2)
 }
 
+func TestMustGetAll(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "a", "a")
+	MustSetValue(ctn, "b", "b")
+	ss := MustGetAll[string](ctx, ctn)
+	assert.DeepEqual(t, ss, map[string]string{"a": "a", "b": "b"})
+}
+
+func TestMustGetAllPanic(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, errors.New("error")
+	})
+	assert.Panics(t, func() {
+		MustGetAll[string](ctx, ctn)
+	})
+}
+
 func TestGetAllError(t *testing.T) {
 	ctx := context.Background()
 	ctn := new(Container)
@@ -227,3 +856,246 @@ func TestGetAllError(t *testing.T) {
 	assert.Equal(t, serviceErr.Key, newKey[string](""))
 	assert.ErrorEqual(t, err, "service string: error")
 }
+
+func TestGetAllConcurrent(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	sharedCalls := 0
+	MustSet(ctn, "shared", func(ctx context.Context, ctn *Container) (int, Close, error) {
+		sharedCalls++
+		return 1, nil, nil
+	})
+	for _, name := range []string{"a", "b", "c", "d"} {
+		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			i, err := Get[int](ctx, ctn, "shared")
+			if err != nil {
+				return "", nil, err
+			}
+			return name + strconv.Itoa(i), nil, nil
+		})
+	}
+	ss, err := GetAllConcurrent[string](ctx, ctn, 4)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, ss, map[string]string{"a": "a1", "b": "b1", "c": "c1", "d": "d1"})
+	assert.Equal(t, sharedCalls, 1)
+}
+
+func TestGetAllConcurrentEmpty(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	ss, err := GetAllConcurrent[string](ctx, ctn, 4)
+	assert.NoError(t, err)
+	assert.MapEmpty(t, ss)
+}
+
+func TestGetAllConcurrentError(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	for _, name := range []string{"a", "b"} {
+		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "", nil, errors.New("error")
+		})
+	}
+	ss, err := GetAllConcurrent[string](ctx, ctn, 4)
+	assert.ErrorEqual(t, err, "service string(a): error\nservice string(b): error")
+	assert.MapEmpty(t, ss)
+}
+
+func TestGetAllConcurrentErrorPartial(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "a", "a")
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, errors.New("error")
+	})
+	ss, err := GetAllConcurrent[string](ctx, ctn, 4)
+	assert.ErrorEqual(t, err, "service string(b): error")
+	assert.MapEmpty(t, ss)
+	ok, err := IsInitialized[string](ctn, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestMustGetAllConcurrent(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "a", "a")
+	MustSetValue(ctn, "b", "b")
+	ss := MustGetAllConcurrent[string](ctx, ctn, 4)
+	assert.DeepEqual(t, ss, map[string]string{"a": "a", "b": "b"})
+}
+
+func TestMustGetAllConcurrentPanic(t *testing.T) {
+	ctx := context.Background()
+	ctn := newTestContainerCycle()
+	assert.Panics(t, func() {
+		MustGetAllConcurrent[string](ctx, ctn, 4)
+	})
+}
+
+func TestGetAllConcurrentErrorCycle(t *testing.T) {
+	ctx := context.Background()
+	ctn := newTestContainerCycle()
+	_, err := GetAllConcurrent[string](ctx, ctn, 4)
+	assert.ErrorIs(t, err, ErrCycle)
+}
+
+func TestGetNames(t *testing.T) {
+	ctn := new(Container)
+	builderCalled := false
+	for _, name := range []string{"b", "a"} {
+		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			builderCalled = true
+			return "", nil, nil
+		})
+	}
+	MustSetValue(ctn, "c", 0)
+	names := GetNames[string](ctn)
+	assert.DeepEqual(t, names, []string{"a", "b"})
+	assert.False(t, builderCalled)
+}
+
+func TestGetAllOrdered(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	calls := 0
+	for _, name := range []string{"c", "a", "b"} {
+		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			calls++
+			return name + "1", nil, nil
+		})
+	}
+	MustSetValue(ctn, "", 1)
+	nss, err := GetAllOrdered[string](ctx, ctn)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, nss, []NamedService[string]{
+		{Name: "a", Service: "a1"},
+		{Name: "b", Service: "b1"},
+		{Name: "c", Service: "c1"},
+	})
+	assert.Equal(t, calls, 3)
+}
+
+func TestGetAllOrderedError(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	for _, name := range []string{"a", "b"} {
+		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "", nil, errors.New("error")
+		})
+	}
+	_, err := GetAllOrdered[string](ctx, ctn)
+	assert.ErrorEqual(t, err, "service string(a): error")
+}
+
+func TestGetAllPartial(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "a", "a")
+	for _, name := range []string{"b", "c"} {
+		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "", nil, errors.New("error")
+		})
+	}
+	ss, err := GetAllPartial[string](ctx, ctn)
+	assert.DeepEqual(t, ss, map[string]string{"a": "a"})
+	assert.ErrorEqual(t, err, "service string(b): error\nservice string(c): error")
+	var serviceErr *ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+}
+
+func TestGetAllPartialEmpty(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	ss, err := GetAllPartial[string](ctx, ctn)
+	assert.NoError(t, err)
+	assert.MapEmpty(t, ss)
+}
+
+func TestGetAllInRegistrationOrder(t *testing.T) {
+	ctx := context.Background()
+	parent := new(Container)
+	for _, name := range []string{"c", "a"} {
+		MustSetValue(parent, name, "parent-"+name)
+	}
+	child := parent.NewChild()
+	for _, name := range []string{"b", "c", "d"} {
+		MustSetValue(child, name, "child-"+name)
+	}
+	MustSetValue(child, "", 1)
+	nss, err := GetAllInRegistrationOrder[string](ctx, child)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, nss, []NamedService[string]{
+		{Name: "c", Service: "child-c"},
+		{Name: "a", Service: "parent-a"},
+		{Name: "b", Service: "child-b"},
+		{Name: "d", Service: "child-d"},
+	})
+}
+
+func TestGetAllInRegistrationOrderReset(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "a", "a")
+	err := ctn.Reset(ctx)
+	assert.NoError(t, err)
+	MustSetValue(ctn, "b", "b")
+	nss, err := GetAllInRegistrationOrder[string](ctx, ctn)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, nss, []NamedService[string]{{Name: "b", Service: "b"}})
+}
+
+func TestGetAllErrorContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctn := new(Container)
+	calls := 0
+	for _, name := range []string{"a", "b", "c"} {
+		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			calls++
+			cancel()
+			return name, nil, nil
+		})
+	}
+	_, err := GetAll[string](ctx, ctn)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, calls, 1)
+	_, err = GetAllOrdered[string](ctx, ctn)
+	assert.ErrorIs(t, err, context.Canceled)
+	ss, err := GetAllPartial[string](ctx, ctn)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.MapEmpty(t, ss)
+	assert.Equal(t, calls, 1)
+}
+
+func TestIsInitialized(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "", "test")
+	MustSetFactory(ctn, "", func(ctx context.Context, ctn *Container) (int, Close, error) {
+		return 1, nil, nil
+	})
+	ok, err := IsInitialized[string](ctn, "")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	MustGet[string](ctx, ctn, "")
+	ok, err = IsInitialized[string](ctn.NewChild(), "")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	MustGet[int](ctx, ctn, "")
+	ok, err = IsInitialized[int](ctn, "")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	err = ctn.Close(ctx)
+	assert.NoError(t, err)
+	ok, err = IsInitialized[string](ctn, "")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestIsInitializedErrorNotSet(t *testing.T) {
+	ctn := new(Container)
+	_, err := IsInitialized[string](ctn, "")
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.ErrorEqual(t, err, "service string: not set")
+}
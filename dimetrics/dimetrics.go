@@ -0,0 +1,238 @@
+// Package dimetrics provides Prometheus metrics for [di].
+//
+// It doesn't depend on the Prometheus client library: the metrics are written in the Prometheus text exposition format.
+//
+// The metrics are labeled by the name of the [di.Container], and the type, name and version of the service.
+// The names of the services should have a low cardinality.
+package dimetrics
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pierrre/di"
+)
+
+// DefaultBuckets are the default buckets of the build duration histogram, in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics collects the metrics of [di.Container]s.
+//
+// It exposes:
+//   - di_service_builds_total: counter of builds
+//   - di_service_build_errors_total: counter of failed builds
+//   - di_service_build_duration_seconds: histogram of build durations
+//   - di_services_initialized: gauge of initialized services
+type Metrics struct {
+	buckets     []float64
+	mu          sync.Mutex
+	services    map[serviceID]*serviceMetrics
+	initialized map[string]int
+}
+
+// serviceID identifies a service in an attached [di.Container].
+type serviceID struct {
+	container string
+	key       di.Key
+}
+
+type serviceMetrics struct {
+	builds       uint64
+	buildErrors  uint64
+	bucketCounts []uint64
+	durationSum  float64
+	initialized  bool
+}
+
+// New returns a new [Metrics].
+//
+// If buckets is empty, [DefaultBuckets] is used.
+func New(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &Metrics{
+		buckets:     buckets,
+		services:    make(map[serviceID]*serviceMetrics),
+		initialized: make(map[string]int),
+	}
+}
+
+// Attach attaches the [Metrics] to a [di.Container].
+//
+// The name is the value of the "container" label.
+// It can be attached to several [di.Container]s, with a different name for each of them.
+func (m *Metrics) Attach(ctn *di.Container, name string) {
+	m.addContainer(name)
+	ctn.OnEvent(func(ev di.Event) {
+		m.handleEvent(name, ev)
+	})
+}
+
+// addContainer adds the container to the gauge of initialized services, so it is exposed even if no service is initialized.
+func (m *Metrics) addContainer(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.initialized[name]
+	if !ok {
+		m.initialized[name] = 0
+	}
+}
+
+func (m *Metrics) handleEvent(container string, ev di.Event) {
+	id := serviceID{
+		container: container,
+		key:       ev.Key,
+	}
+	switch ev.Type { //nolint:exhaustive // We only handle the "end" events.
+	case di.EventBuildEnd:
+		m.observeBuild(id, ev)
+	case di.EventCloseEnd:
+		m.observeClose(id)
+	}
+}
+
+func (m *Metrics) observeBuild(id serviceID, ev di.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sm := m.getServiceMetrics(id)
+	sm.builds++
+	if ev.Err != nil {
+		sm.buildErrors++
+	}
+	d := ev.Duration.Seconds()
+	sm.durationSum += d
+	for i, b := range m.buckets {
+		if d <= b {
+			sm.bucketCounts[i]++
+		}
+	}
+	if ev.Err == nil && !sm.initialized {
+		sm.initialized = true
+		m.initialized[id.container]++
+	}
+}
+
+func (m *Metrics) observeClose(id serviceID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sm := m.getServiceMetrics(id)
+	if sm.initialized {
+		sm.initialized = false
+		m.initialized[id.container]--
+	}
+}
+
+func (m *Metrics) getServiceMetrics(id serviceID) *serviceMetrics {
+	sm, ok := m.services[id]
+	if !ok {
+		sm = &serviceMetrics{
+			bucketCounts: make([]uint64, len(m.buckets)),
+		}
+		m.services[id] = sm
+	}
+	return sm
+}
+
+// WriteTo writes the metrics to a [io.Writer], in the Prometheus text exposition format.
+//
+// It implements [io.WriterTo].
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	m.write(bw)
+	err := bw.Flush()
+	return cw.n, err //nolint:wrapcheck // We don't need to wrap.
+}
+
+// ServeHTTP implements [http.Handler].
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+func (m *Metrics) write(w *bufio.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]serviceID, 0, len(m.services))
+	for id := range m.services {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b serviceID) int {
+		return cmp.Or(
+			cmp.Compare(a.container, b.container),
+			cmp.Compare(a.key.String(), b.key.String()),
+		)
+	})
+	writeHeader(w, "di_service_builds_total", "counter", "Number of service builds.")
+	for _, id := range ids {
+		_, _ = fmt.Fprintf(w, "di_service_builds_total{%s} %d\n", formatLabels(id), m.services[id].builds)
+	}
+	writeHeader(w, "di_service_build_errors_total", "counter", "Number of failed service builds.")
+	for _, id := range ids {
+		_, _ = fmt.Fprintf(w, "di_service_build_errors_total{%s} %d\n", formatLabels(id), m.services[id].buildErrors)
+	}
+	writeHeader(w, "di_service_build_duration_seconds", "histogram", "Duration of service builds.")
+	for _, id := range ids {
+		m.writeHistogram(w, id, m.services[id])
+	}
+	writeHeader(w, "di_services_initialized", "gauge", "Number of initialized services.")
+	for _, container := range slices.Sorted(maps.Keys(m.initialized)) {
+		_, _ = fmt.Fprintf(w, "di_services_initialized{container=\"%s\"} %d\n", labelValueEscaper.Replace(container), m.initialized[container])
+	}
+}
+
+func (m *Metrics) writeHistogram(w *bufio.Writer, id serviceID, sm *serviceMetrics) {
+	labels := formatLabels(id)
+	for i, b := range m.buckets {
+		_, _ = fmt.Fprintf(w, "di_service_build_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, formatFloat(b), sm.bucketCounts[i])
+	}
+	_, _ = fmt.Fprintf(w, "di_service_build_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, sm.builds)
+	_, _ = fmt.Fprintf(w, "di_service_build_duration_seconds_sum{%s} %s\n", labels, formatFloat(sm.durationSum))
+	_, _ = fmt.Fprintf(w, "di_service_build_duration_seconds_count{%s} %d\n", labels, sm.builds)
+}
+
+func writeHeader(w *bufio.Writer, name, typ, help string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func formatLabels(id serviceID) string {
+	return fmt.Sprintf(
+		"container=\"%s\",type=\"%s\",name=\"%s\",version=\"%d\"",
+		labelValueEscaper.Replace(id.container),
+		labelValueEscaper.Replace(id.key.Type),
+		labelValueEscaper.Replace(id.key.Name),
+		id.key.Version,
+	)
+}
+
+var labelValueEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err //nolint:wrapcheck // We don't need to wrap.
+}
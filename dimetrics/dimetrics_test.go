@@ -0,0 +1,102 @@
+package dimetrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pierrre/assert"
+	"github.com/pierrre/di"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	m := New(1)
+	ctn := new(di.Container)
+	m.Attach(ctn, "main")
+	di.MustSetValue(ctn, "a", "")
+	di.MustSetValue(ctn, "b\"", "")
+	di.MustSet(ctn, "", func(ctx context.Context, ctn *di.Container) (int, di.Close, error) {
+		return 0, nil, errors.New("error")
+	})
+	di.MustGet[string](ctx, ctn, "a")
+	di.MustGet[string](ctx, ctn, "b\"")
+	_, _ = di.Get[int](ctx, ctn, "")
+	s := writeMetrics(t, m)
+	assert.StringContains(t, s, `di_service_builds_total{container="main",type="string",name="a",version="0"} 1`)
+	assert.StringContains(t, s, `di_service_builds_total{container="main",type="string",name="b\"",version="0"} 1`)
+	assert.StringContains(t, s, `di_service_build_errors_total{container="main",type="int",name="",version="0"} 1`)
+	assert.StringContains(t, s, `di_service_build_duration_seconds_bucket{container="main",type="string",name="a",version="0",le="1"} 1`)
+	assert.StringContains(t, s, `di_service_build_duration_seconds_bucket{container="main",type="string",name="a",version="0",le="+Inf"} 1`)
+	assert.StringContains(t, s, `di_service_build_duration_seconds_count{container="main",type="string",name="a",version="0"} 1`)
+	assert.StringContains(t, s, "# TYPE di_service_build_duration_seconds histogram\n")
+	assert.StringContains(t, s, "di_services_initialized{container=\"main\"} 2\n")
+	err := ctn.Close(ctx)
+	assert.NoError(t, err)
+	s = writeMetrics(t, m)
+	assert.StringContains(t, s, "di_services_initialized{container=\"main\"} 0\n")
+}
+
+func TestMetricsVersions(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	ctn := new(di.Container)
+	m.Attach(ctn, "main")
+	di.MustSetVersioned(ctn, "", 1, func(ctx context.Context, ctn *di.Container) (string, di.Close, error) {
+		return "", nil, nil
+	})
+	di.MustSetVersioned(ctn, "", 2, func(ctx context.Context, ctn *di.Container) (string, di.Close, error) {
+		return "", nil, nil
+	})
+	di.MustGetVersioned[string](ctx, ctn, "", 1)
+	di.MustGetVersioned[string](ctx, ctn, "", 2)
+	s := writeMetrics(t, m)
+	assert.StringContains(t, s, `di_service_builds_total{container="main",type="string",name="",version="1"} 1`)
+	assert.StringContains(t, s, `di_service_builds_total{container="main",type="string",name="",version="2"} 1`)
+}
+
+func TestMetricsMultipleContainers(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	ctn1 := new(di.Container)
+	m.Attach(ctn1, "ctn1")
+	ctn2 := new(di.Container)
+	m.Attach(ctn2, "ctn2")
+	for _, ctn := range []*di.Container{ctn1, ctn2} {
+		di.MustSetValue(ctn, "", "")
+		di.MustGet[string](ctx, ctn, "")
+	}
+	s := writeMetrics(t, m)
+	assert.StringContains(t, s, `di_service_builds_total{container="ctn1",type="string",name="",version="0"} 1`)
+	assert.StringContains(t, s, `di_service_builds_total{container="ctn2",type="string",name="",version="0"} 1`)
+	assert.StringContains(t, s, "di_services_initialized{container=\"ctn1\"} 1\n")
+	assert.StringContains(t, s, "di_services_initialized{container=\"ctn2\"} 1\n")
+	err := ctn1.Close(ctx)
+	assert.NoError(t, err)
+	s = writeMetrics(t, m)
+	assert.StringContains(t, s, "di_services_initialized{container=\"ctn1\"} 0\n")
+	assert.StringContains(t, s, "di_services_initialized{container=\"ctn2\"} 1\n")
+}
+
+func TestMetricsServeHTTP(t *testing.T) {
+	m := New()
+	m.Attach(new(di.Container), "main")
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody)
+	m.ServeHTTP(w, req)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.StringHasPrefix(t, w.Header().Get("Content-Type"), "text/plain")
+	assert.StringContains(t, w.Body.String(), "di_services_initialized{container=\"main\"} 0\n")
+}
+
+func writeMetrics(tb testing.TB, m *Metrics) string {
+	tb.Helper()
+	sb := new(strings.Builder)
+	n, err := m.WriteTo(sb)
+	assert.NoError(tb, err)
+	assert.Equal(tb, n, int64(sb.Len()))
+	return sb.String()
+}
@@ -0,0 +1,76 @@
+// Package diplugin provides a [di.Builder] for services built from Go plugins.
+//
+// It is separated from [di], because the [plugin] package requires cgo.
+package diplugin
+
+import (
+	"context"
+	"fmt"
+	"plugin"
+
+	"github.com/pierrre/di"
+)
+
+// Set sets a service to a [di.Container], that is built from a Go plugin.
+//
+// When the service is initialized, the plugin is opened with [plugin.Open], and the symbol is looked up.
+// The symbol must be one of:
+//   - a function with the signature of [di.Builder]
+//   - a variable of type [di.Builder], or of the function type
+//   - a constructor function: func() (S, error), or a variable of this type
+//
+// The errors (missing file, missing symbol, wrong symbol type) are returned by the build.
+func Set[S any](ctn *di.Container, name string, pluginPath string, symbolName string, opts ...di.ServiceOption) error {
+	return di.Set(ctn, name, newBuilder[S](pluginPath, symbolName), opts...)
+}
+
+// MustSet calls [Set] and panics if there is an error.
+func MustSet[S any](ctn *di.Container, name string, pluginPath string, symbolName string, opts ...di.ServiceOption) {
+	di.MustSet(ctn, name, newBuilder[S](pluginPath, symbolName), opts...)
+}
+
+func newBuilder[S any](pluginPath string, symbolName string) di.Builder[S] {
+	return func(ctx context.Context, ctn *di.Container) (s S, cl di.Close, err error) {
+		p, err := plugin.Open(pluginPath)
+		if err != nil {
+			return s, nil, fmt.Errorf("open plugin %q: %w", pluginPath, err)
+		}
+		sym, err := p.Lookup(symbolName)
+		if err != nil {
+			return s, nil, fmt.Errorf("plugin %q: %w", pluginPath, err)
+		}
+		b, err := getSymbolBuilder[S](sym)
+		if err != nil {
+			return s, nil, fmt.Errorf("plugin %q: symbol %q: %w", pluginPath, symbolName, err)
+		}
+		return b(ctx, ctn)
+	}
+}
+
+// getSymbolBuilder returns a [di.Builder] for a plugin symbol.
+//
+// [plugin.Plugin.Lookup] returns a pointer for an exported variable, and the value for an exported function.
+func getSymbolBuilder[S any](sym any) (di.Builder[S], error) {
+	switch f := sym.(type) {
+	case func(ctx context.Context, ctn *di.Container) (S, di.Close, error):
+		return f, nil
+	case *func(ctx context.Context, ctn *di.Container) (S, di.Close, error):
+		return *f, nil
+	case di.Builder[S]:
+		return f, nil
+	case *di.Builder[S]:
+		return *f, nil
+	case func() (S, error):
+		return newConstructorBuilder(f), nil
+	case *func() (S, error):
+		return newConstructorBuilder(*f), nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", sym)
+}
+
+func newConstructorBuilder[S any](f func() (S, error)) di.Builder[S] {
+	return func(ctx context.Context, ctn *di.Container) (S, di.Close, error) {
+		s, err := f()
+		return s, nil, err
+	}
+}
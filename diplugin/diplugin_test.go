@@ -0,0 +1,62 @@
+package diplugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pierrre/assert"
+	"github.com/pierrre/di"
+)
+
+func TestSetErrorOpen(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(di.Container)
+	err := Set[string](ctn, "", "invalid.so", "Builder")
+	assert.NoError(t, err)
+	_, err = di.Get[string](ctx, ctn, "")
+	var serviceErr *di.ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+	assert.Equal(t, serviceErr.Key, di.KeyFor[string](""))
+	assert.ErrorContains(t, err, `open plugin "invalid.so"`)
+}
+
+func TestMustSetPanic(t *testing.T) {
+	ctn := new(di.Container)
+	MustSet[string](ctn, "", "invalid.so", "Builder")
+	assert.Panics(t, func() {
+		MustSet[string](ctn, "", "invalid.so", "Builder")
+	})
+}
+
+func TestGetSymbolBuilder(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(di.Container)
+	f := func(ctx context.Context, ctn *di.Container) (string, di.Close, error) {
+		return "test", nil, nil
+	}
+	b := di.Builder[string](f)
+	cf := func() (string, error) {
+		return "test", nil
+	}
+	for _, sym := range []any{
+		f,
+		&f,
+		b,
+		&b,
+		cf,
+		&cf,
+	} {
+		pb, err := getSymbolBuilder[string](sym)
+		assert.NoError(t, err)
+		s, _, err := pb(ctx, ctn)
+		assert.NoError(t, err)
+		assert.Equal(t, s, "test")
+	}
+}
+
+func TestGetSymbolBuilderErrorType(t *testing.T) {
+	_, err := getSymbolBuilder[string](func() int {
+		return 0
+	})
+	assert.ErrorEqual(t, err, "unsupported type func() int")
+}
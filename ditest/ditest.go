@@ -0,0 +1,49 @@
+// Package ditest provides test helpers for [di].
+package ditest
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pierrre/di"
+)
+
+// WithMocks returns a copy of ctn with the mocks set, see [di.Container.SnapshotRegistrations].
+//
+// The mocks replace the services of the copy that have the same key, so all the services of the copy get the mocks as dependencies.
+// ctn is not modified.
+// If ctn has parents, a mock of a service set in a parent is set to the returned [di.Container], so the services of the parents don't see it.
+//
+// It panics if a mock can't be set.
+func WithMocks(ctn *di.Container, mocks ...Mock) *di.Container {
+	snapshot := ctn.SnapshotRegistrations()
+	for _, m := range mocks {
+		err := m(snapshot)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return snapshot
+}
+
+// Mock sets a mocked service to a [di.Container].
+//
+// See [NewMock].
+type Mock func(ctn *di.Container) error
+
+// NewMock returns a [Mock] that sets a service value.
+//
+// It replaces the service if it is already set, see [di.Replace].
+func NewMock[S any](name string, value S) Mock {
+	return func(ctn *di.Container) error {
+		b := func(ctx context.Context, ctn *di.Container) (S, di.Close, error) {
+			return value, nil, nil
+		}
+		// The services of a snapshot are not initialized, so there is nothing to close.
+		err := di.Replace(context.Background(), ctn, name, b)
+		if errors.Is(err, di.ErrNotSet) {
+			err = di.Set(ctn, name, b)
+		}
+		return err //nolint:wrapcheck // We don't need to wrap.
+	}
+}
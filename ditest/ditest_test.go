@@ -0,0 +1,43 @@
+package ditest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pierrre/assert"
+	"github.com/pierrre/di"
+)
+
+type subject struct {
+	dep string
+}
+
+func TestWithMocks(t *testing.T) {
+	ctx := context.Background()
+	base := new(di.Container)
+	di.MustSetValue(base, "dep", "real")
+	di.MustSet(base, "", func(ctx context.Context, ctn *di.Container) (*subject, di.Close, error) {
+		return &subject{
+			dep: di.MustGet[string](ctx, ctn, "dep"),
+		}, nil, nil
+	})
+	ctn := WithMocks(base, NewMock("dep", "mock"), NewMock("other", "mock"))
+	s := di.MustGet[*subject](ctx, ctn, "")
+	assert.Equal(t, s.dep, "mock")
+	other := di.MustGet[string](ctx, ctn, "other")
+	assert.Equal(t, other, "mock")
+	s = di.MustGet[*subject](ctx, base, "")
+	assert.Equal(t, s.dep, "real")
+	_, err := di.Get[string](ctx, base, "other")
+	assert.ErrorIs(t, err, di.ErrNotSet)
+}
+
+func TestWithMocksPanic(t *testing.T) {
+	base := new(di.Container)
+	assert.Panics(t, func() {
+		WithMocks(base, func(ctn *di.Container) error {
+			return errors.New("error")
+		})
+	})
+}
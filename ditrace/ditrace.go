@@ -0,0 +1,43 @@
+// Package ditrace provides OpenTelemetry tracing for [di].
+//
+// It is a separate module, so the [di] module doesn't depend on OpenTelemetry.
+package ditrace
+
+import (
+	"context"
+
+	"github.com/pierrre/di"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/pierrre/di/ditrace"
+
+// WithTracing returns a [di.ContainerOption] that starts a span for each service build.
+//
+// The span is named "di.build <key>", and is a child of the span of the [context.Context] given to [di.Get].
+// The builds of the dependencies are children of the span of the service.
+// Because the services are cached, there is only a span for the first build.
+//
+// If tp is nil, the global [trace.TracerProvider] is used.
+func WithTracing(tp trace.TracerProvider) di.ContainerOption {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	tracer := tp.Tracer(instrumentationName)
+	return di.WithBuildHook(func(ctx context.Context, key di.Key) (context.Context, func(err error)) {
+		ctx, span := tracer.Start(ctx, "di.build "+key.String(), trace.WithAttributes(
+			attribute.String("di.service.type", key.Type),
+			attribute.String("di.service.name", key.Name),
+		))
+		return ctx, func(err error) {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}
+	})
+}
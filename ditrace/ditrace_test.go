@@ -0,0 +1,52 @@
+package ditrace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pierrre/assert"
+	"github.com/pierrre/di"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTracing(t *testing.T) {
+	ctx := context.Background()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctn := di.NewContainer(WithTracing(tp))
+	di.MustSet(ctn, "a", func(ctx context.Context, ctn *di.Container) (string, di.Close, error) {
+		di.MustGet[string](ctx, ctn, "b")
+		return "", nil, nil
+	})
+	di.MustSetValue(ctn, "b", "")
+	ctx, span := tp.Tracer("test").Start(ctx, "root")
+	di.MustGet[string](ctx, ctn, "a")
+	di.MustGet[string](ctx, ctn, "a")
+	span.End()
+	spans := exporter.GetSpans()
+	assert.SliceLen(t, spans, 3)
+	assert.Equal(t, spans[0].Name, "di.build string(b)")
+	assert.Equal(t, spans[1].Name, "di.build string(a)")
+	assert.Equal(t, spans[2].Name, "root")
+	assert.Equal(t, spans[0].Parent.SpanID(), spans[1].SpanContext.SpanID())
+	assert.Equal(t, spans[1].Parent.SpanID(), spans[2].SpanContext.SpanID())
+}
+
+func TestWithTracingError(t *testing.T) {
+	ctx := context.Background()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctn := di.NewContainer(WithTracing(tp))
+	di.MustSet(ctn, "", func(ctx context.Context, ctn *di.Container) (string, di.Close, error) {
+		return "", nil, errors.New("error")
+	})
+	_, err := di.Get[string](ctx, ctn, "")
+	assert.Error(t, err)
+	spans := exporter.GetSpans()
+	assert.SliceLen(t, spans, 1)
+	assert.Equal(t, spans[0].Status.Code, codes.Error)
+	assert.Equal(t, spans[0].Status.Description, "error")
+}
@@ -0,0 +1,129 @@
+package di
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Drain closes the [Container] for good, and waits for the in-flight builds before closing the services.
+//
+// After it is called, [Get] and [Set] return [ErrClosed].
+// The [Get] calls made by a [Builder] that is in-flight are still allowed, so the in-flight builds can complete.
+// Then, the services are closed, as with [Container.Close], including the persistent services (see [WithPersistent]).
+//
+// It only applies to the [Container], not to its parents or children.
+// If the [context.Context] is canceled before the in-flight builds are done, it returns the context error, and the services are not closed.
+func (c *Container) Drain(ctx context.Context) error {
+	if isClosingContext(ctx) {
+		return ErrCloseReentrant
+	}
+	err := c.drain.close(ctx)
+	if err != nil {
+		return err
+	}
+	return c.closeFinal(ctx)
+}
+
+// Shutdown closes the [Container] for good, and closes the services, including the persistent services (see [WithPersistent]).
+//
+// After it is called, [Get] and [Set] return [ErrClosed].
+// Contrary to [Container.Drain], it doesn't wait for the in-flight builds.
+// Contrary to [Container.Close], the [Container] can't be used again, unless [Container.Reset] is called.
+func (c *Container) Shutdown(ctx context.Context) error {
+	if isClosingContext(ctx) {
+		return ErrCloseReentrant
+	}
+	c.drain.closed.Store(true)
+	return c.closeFinal(ctx)
+}
+
+// closeFinal closes all the services of the [Container], including the persistent services, in the close order.
+func (c *Container) closeFinal(ctx context.Context) error {
+	sws := c.services.getValues()
+	sortServiceWrappersCloseOrder(sws)
+	return c.closeServiceWrappers(ctx, sws)
+}
+
+// drainState tracks the in-flight builds of a [Container], for [Container.Drain].
+//
+// Only the builds are tracked, so the [Get] calls of the initialized services don't write to a shared counter.
+type drainState struct {
+	closed   atomic.Bool
+	inFlight atomic.Int64
+	mu       sync.Mutex
+	done     chan struct{} // Closed when there is no in-flight build after close.
+}
+
+// check returns [ErrClosed] if the [Container] is closed, unless the call is made by an in-flight [Builder].
+func (d *drainState) check(ctx context.Context) error {
+	if d.closed.Load() && getBuildState(ctx) == nil {
+		return ErrClosed
+	}
+	return nil
+}
+
+// enterBuild registers an in-flight build.
+// The [context.Context] contains the [buildState] of the built service.
+// If the [Container] is closed, it returns [ErrClosed], unless the build is nested in an in-flight build.
+//
+// It is checked again after the registration, because the [Container] could have been closed after [drainState.check].
+func (d *drainState) enterBuild(ctx context.Context) error {
+	d.inFlight.Add(1)
+	if d.closed.Load() {
+		st := getBuildState(ctx)
+		if st == nil || st.previous == nil {
+			d.exit()
+			return ErrClosed
+		}
+	}
+	return nil
+}
+
+func (d *drainState) exit() {
+	if d.inFlight.Add(-1) == 0 && d.closed.Load() {
+		d.setDone()
+	}
+}
+
+// close marks the [Container] as closed, and waits for the in-flight builds.
+func (d *drainState) close(ctx context.Context) error {
+	d.closed.Store(true)
+	if d.inFlight.Load() == 0 {
+		d.setDone()
+	}
+	select {
+	case <-d.getDone():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // We don't need to wrap.
+	}
+}
+
+func (d *drainState) getDone() chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.done == nil {
+		d.done = make(chan struct{})
+	}
+	return d.done
+}
+
+func (d *drainState) setDone() {
+	done := d.getDone()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	select {
+	case <-done:
+	default:
+		close(done)
+	}
+}
+
+// reset reopens the [Container].
+func (d *drainState) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed.Store(false)
+	d.done = nil
+}
@@ -0,0 +1,165 @@
+package di
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+	"github.com/pierrre/go-libs/goroutine"
+)
+
+func TestContainerDrain(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	closed := 0
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		close(started)
+		<-release
+		b, err := Get[string](ctx, ctn, "b") // Allowed, because it is in-flight.
+		return "a" + b, func(ctx context.Context) error {
+			closed++
+			return nil
+		}, err
+	})
+	MustSetValue(ctn, "b", "b")
+	var s string
+	var getErr error
+	wait := goroutine.Wait(ctx, func(ctx context.Context) {
+		s, getErr = Get[string](ctx, ctn, "a")
+	})
+	<-started
+	drainErr := make(chan error, 1)
+	goroutine.Start(ctx, func(ctx context.Context) {
+		drainErr <- ctn.Drain(ctx)
+	})
+	for !ctn.drain.closed.Load() {
+		time.Sleep(1 * time.Millisecond)
+	}
+	_, err := Get[string](ctx, ctn, "b")
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.ErrorEqual(t, err, "service string(b): closed")
+	err = SetValue(ctn, "c", "c")
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = SetIfAbsent(ctn, "c", newValueBuilder("c"))
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = GetOrSet(ctx, ctn, "c", newValueBuilder("c"))
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = GetOrBuild(ctx, ctn, "c", newValueBuilder("c"))
+	assert.ErrorIs(t, err, ErrClosed)
+	close(release)
+	wait()
+	assert.NoError(t, getErr)
+	assert.Equal(t, s, "ab")
+	err = <-drainErr
+	assert.NoError(t, err)
+	assert.Equal(t, closed, 1)
+	err = ctn.Drain(ctx)
+	assert.NoError(t, err)
+}
+
+func TestContainerDrainErrorContextCanceled(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		close(started)
+		<-release
+		return "", nil, nil
+	})
+	wait := goroutine.Wait(ctx, func(ctx context.Context) {
+		_, _ = Get[string](ctx, ctn, "")
+	})
+	<-started
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	err := ctn.Drain(cancelCtx)
+	assert.ErrorIs(t, err, context.Canceled)
+	close(release)
+	wait()
+}
+
+func TestContainerDrainErrorReentrant(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			return ctn.Drain(ctx)
+		}, nil
+	})
+	MustGet[string](ctx, ctn, "")
+	err := ctn.Drain(ctx)
+	assert.ErrorIs(t, err, ErrCloseReentrant)
+}
+
+func TestContainerShutdown(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closed := 0
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			closed++
+			return nil
+		}, nil
+	})
+	MustGet[string](ctx, ctn, "")
+	err := ctn.Shutdown(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closed, 1)
+	_, err = Get[string](ctx, ctn, "")
+	assert.ErrorIs(t, err, ErrClosed)
+	err = SetValue(ctn, "a", "a")
+	assert.ErrorIs(t, err, ErrClosed)
+	err = ctn.Reset(ctx)
+	assert.NoError(t, err)
+	MustSetValue(ctn, "a", "a")
+	assert.Equal(t, MustGet[string](ctx, ctn, "a"), "a")
+}
+
+func TestContainerShutdownPersistent(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closed := 0
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			closed++
+			return nil
+		}, nil
+	}, WithPersistent())
+	MustGet[string](ctx, ctn, "")
+	err := ctn.Shutdown(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closed, 1)
+}
+
+func TestContainerDrainPersistent(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closed := 0
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			closed++
+			return nil
+		}, nil
+	}, WithPersistent())
+	MustGet[string](ctx, ctn, "")
+	err := ctn.Drain(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closed, 1)
+}
+
+func TestContainerShutdownErrorReentrant(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			return ctn.Shutdown(ctx)
+		}, nil
+	})
+	MustGet[string](ctx, ctn, "")
+	err := ctn.Shutdown(ctx)
+	assert.ErrorIs(t, err, ErrCloseReentrant)
+}
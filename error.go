@@ -1,8 +1,12 @@
 package di
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"runtime/debug"
+	"strings"
 )
 
 var (
@@ -11,9 +15,55 @@ var (
 	// ErrAlreadySet is returned when a service is already set.
 	ErrAlreadySet = errors.New("already set")
 	// ErrCycle is returned when a cycle is detected.
+	// The returned error is a [CycleError], that contains the keys of the services in the cycle.
 	ErrCycle = errors.New("cycle")
+	// ErrCloseReentrant is returned when the [Container] is closed from a [Close] function.
+	ErrCloseReentrant = errors.New("close reentrant")
+	// ErrNilService is returned when a [Builder] returns a nil service.
+	ErrNilService = errors.New("nil service")
+	// ErrAlreadyInitialized is returned when a service can't be modified because it is already initialized.
+	ErrAlreadyInitialized = errors.New("already initialized")
+	// ErrMaxDepthExceeded is returned when the dependency chain is deeper than the maximum depth.
+	//
+	// See [WithMaxDepth].
+	ErrMaxDepthExceeded = errors.New("max depth exceeded")
+	// ErrClosed is returned when the [Container] is closed for good, see [Container.Shutdown] and [Container.Drain].
+	ErrClosed = errors.New("closed")
+	// ErrDependencyTrackingDisabled is returned when the [Dependency] is requested, but the tracking is disabled.
+	//
+	// See [WithoutDependencyTracking].
+	ErrDependencyTrackingDisabled = errors.New("dependency tracking disabled")
+	// ErrCloseTrackingDisabled is returned by [Container.AssertAllClosed] when the tracking is disabled.
+	//
+	// See [WithCloseTracking].
+	ErrCloseTrackingDisabled = errors.New("close tracking disabled")
 )
 
+// errReplaced is returned by a [serviceWrapper] that was replaced, so the caller gets the new one from the [Container].
+var errReplaced = errors.New("replaced")
+
+// CycleError represents a cycle between services.
+//
+// It matches [ErrCycle] with [errors.Is].
+type CycleError struct {
+	// Keys contains the keys of the services in the cycle, in order.
+	// The first and last keys are the same.
+	Keys []Key
+}
+
+func (err *CycleError) Error() string {
+	ss := make([]string, len(err.Keys))
+	for i, key := range err.Keys {
+		ss[i] = key.String()
+	}
+	return fmt.Sprintf("%v: %s", ErrCycle, strings.Join(ss, " -> "))
+}
+
+// Is returns true if the target is [ErrCycle].
+func (err *CycleError) Is(target error) bool {
+	return target == ErrCycle
+}
+
 // ServiceError represents an error related to a service.
 type ServiceError struct {
 	error
@@ -38,30 +88,70 @@ func wrapServiceError(err error, key Key) error {
 	}
 }
 
-func wrapReturnServiceError(perr *error, key Key) { //nolint:gocritic // We need a pointer of error.
+// ErrorWrapper wraps an error related to a service.
+//
+// The returned error should wrap the original error, in order to keep [errors.Is] and [errors.As] working with the sentinel errors.
+//
+// See [WithErrorWrapper].
+type ErrorWrapper func(err error, key Key) error
+
+func (c *Container) wrapServiceError(err error, key Key) error {
+	if err == nil {
+		return nil
+	}
+	if c.config.errorWrapper != nil {
+		return c.config.errorWrapper(err, key)
+	}
+	return wrapServiceError(err, key)
+}
+
+func (c *Container) wrapReturnServiceError(perr *error, key Key) { //nolint:gocritic // We need a pointer of error.
 	err := *perr
-	*perr = wrapServiceError(err, key)
+	*perr = c.wrapServiceError(err, key)
+}
+
+// TypeMismatchError is returned when a service doesn't have the requested type.
+type TypeMismatchError struct {
+	Expected reflect.Type
+	Actual   reflect.Type
+}
+
+func (err *TypeMismatchError) Error() string {
+	return fmt.Sprintf("type mismatch: expected %v, got %v", err.Expected, err.Actual)
 }
 
 // PanicError represents a recovered panic error.
 type PanicError struct {
 	Recovered any
+	// Stack is the stack trace of the goroutine when the panic was recovered.
+	Stack []byte
+	// Chain contains the keys of the services that were being built, from the service that panicked to the root service.
+	//
+	// See [BuildChain].
+	Chain []Key
 }
 
 func (err *PanicError) Error() string {
 	return fmt.Sprintf("panic: %v", err.Recovered)
 }
 
+// ErrorStack returns the error message followed by the stack trace.
+func (err *PanicError) ErrorStack() string {
+	return err.Error() + "\n" + string(err.Stack)
+}
+
 func (err *PanicError) Unwrap() error {
 	errw, _ := err.Recovered.(error)
 	return errw
 }
 
-func recoverPanicToError(perr *error) { //nolint:gocritic // We need a pointer of error.
+func recoverPanicToError(ctx context.Context, perr *error) { //nolint:gocritic // We need a pointer of error.
 	r := recover()
 	if r != nil {
 		*perr = &PanicError{
 			Recovered: r,
+			Stack:     debug.Stack(),
+			Chain:     BuildChain(ctx),
 		}
 	}
 }
@@ -0,0 +1,80 @@
+package di
+
+import (
+	"sync"
+	"time"
+)
+
+// OnEvent registers an [EventHandler] to the [Container].
+//
+// Multiple handlers can be registered, and they are called in the registration order.
+func (c *Container) OnEvent(h EventHandler) {
+	c.events.add(h)
+}
+
+func (c *Container) emitEvent(ev Event) {
+	for _, h := range c.events.get() {
+		h(ev)
+	}
+}
+
+// EventHandler handles an [Event].
+//
+// It is called synchronously, so it should be fast.
+type EventHandler func(ev Event)
+
+// Event represents a lifecycle event of a service.
+type Event struct {
+	Key  Key
+	Type EventType
+	// Duration is the duration of the build or close, for the "end" events.
+	Duration time.Duration
+	// Err is the error of the build or close, for the "end" events.
+	Err error
+}
+
+// EventType represents the type of an [Event].
+type EventType int
+
+// EventType values.
+const (
+	// EventBuildStart is emitted before the [Builder] is called.
+	EventBuildStart EventType = iota + 1
+	// EventBuildEnd is emitted after the [Builder] is called.
+	EventBuildEnd
+	// EventCloseStart is emitted before the [Close] function is called.
+	EventCloseStart
+	// EventCloseEnd is emitted after the [Close] function is called.
+	EventCloseEnd
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventBuildStart:
+		return "build_start"
+	case EventBuildEnd:
+		return "build_end"
+	case EventCloseStart:
+		return "close_start"
+	case EventCloseEnd:
+		return "close_end"
+	}
+	return "unknown"
+}
+
+type eventHandlers struct {
+	mu sync.Mutex
+	hs []EventHandler
+}
+
+func (ehs *eventHandlers) add(h EventHandler) {
+	ehs.mu.Lock()
+	defer ehs.mu.Unlock()
+	ehs.hs = append(ehs.hs, h)
+}
+
+func (ehs *eventHandlers) get() []EventHandler {
+	ehs.mu.Lock()
+	defer ehs.mu.Unlock()
+	return ehs.hs[:len(ehs.hs):len(ehs.hs)]
+}
@@ -0,0 +1,61 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestContainerOnEvent(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var types []string
+	ctn.OnEvent(func(ev Event) {
+		types = append(types, ev.Key.Name+":"+ev.Type.String())
+	})
+	var errs []error
+	ctn.OnEvent(func(ev Event) {
+		if ev.Err != nil {
+			errs = append(errs, ev.Err)
+		}
+	})
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "b")
+		return "", func(ctx context.Context) error {
+			return errors.New("error")
+		}, nil
+	})
+	MustSetValue(ctn, "b", "")
+	MustSet(ctn, "c", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		panic("test")
+	})
+	MustSetFactory(ctn, "", func(ctx context.Context, ctn *Container) (int, Close, error) {
+		return 0, nil, nil
+	})
+	MustGet[string](ctx, ctn, "a")
+	_, _ = Get[string](ctx, ctn, "c")
+	err := ctn.Close(ctx)
+	assert.Error(t, err)
+	assert.DeepEqual(t, types, []string{
+		"a:build_start",
+		"b:build_start",
+		"b:build_end",
+		"a:build_end",
+		"c:build_start",
+		"c:build_end",
+		"a:close_start",
+		"a:close_end",
+		"b:close_start",
+		"b:close_end",
+	})
+	assert.SliceLen(t, errs, 2)
+	var panicErr *PanicError
+	assert.ErrorAs(t, errs[0], &panicErr)
+	assert.ErrorEqual(t, errs[1], "error")
+}
+
+func TestEventTypeString(t *testing.T) {
+	assert.Equal(t, EventType(0).String(), "unknown")
+}
@@ -0,0 +1,180 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT writes the [Dependency] tree to a [io.Writer], in the Graphviz DOT format.
+//
+// See [DependencyDOT].
+func (d *Dependency) WriteDOT(w io.Writer) error {
+	_, err := io.WriteString(w, DependencyDOT([]*Dependency{d}))
+	return err //nolint:wrapcheck // We don't need to wrap.
+}
+
+// DependencyDOT returns the [Dependency] trees in the Graphviz DOT format.
+//
+// There is a node for each service, and an edge from each service to its dependencies.
+// The dependencies shared by several services are merged into a single node.
+// The edges of the optional dependencies are dashed.
+func DependencyDOT(deps []*Dependency) string {
+	sb := new(strings.Builder)
+	sb.WriteString("digraph {\n")
+	walkDependencyGraph(deps, func(d *Dependency) {
+		id := strconv.Quote(d.graphKey())
+		_, _ = fmt.Fprintf(sb, "\t%s;\n", id)
+		for _, dd := range d.Dependencies {
+			attrs := ""
+			if dd.Optional {
+				attrs = " [style=dashed]"
+			}
+			_, _ = fmt.Fprintf(sb, "\t%s -> %s%s;\n", id, strconv.Quote(dd.graphKey()), attrs)
+		}
+	})
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// WriteMermaid writes the [Dependency] tree to a [io.Writer], as a Mermaid flowchart.
+//
+// See [DependencyMermaid].
+func (d *Dependency) WriteMermaid(w io.Writer) error {
+	_, err := io.WriteString(w, DependencyMermaid([]*Dependency{d}))
+	return err //nolint:wrapcheck // We don't need to wrap.
+}
+
+// DependencyMermaid returns the [Dependency] trees as a Mermaid flowchart.
+//
+// There is a node for each service, and an edge from each service to its dependencies.
+// The dependencies shared by several services are merged into a single node.
+// The edges of the optional dependencies are dotted.
+func DependencyMermaid(deps []*Dependency) string {
+	sb := new(strings.Builder)
+	sb.WriteString("graph TD\n")
+	ids := make(map[string]string)
+	getID := func(d *Dependency) string {
+		k := d.graphKey()
+		id, ok := ids[k]
+		if !ok {
+			id = "n" + strconv.Itoa(len(ids))
+			ids[k] = id
+		}
+		return id
+	}
+	walkDependencyGraph(deps, func(d *Dependency) {
+		id := getID(d)
+		_, _ = fmt.Fprintf(sb, "\t%s[\"%s\"]\n", id, mermaidEscaper.Replace(d.graphKey()))
+		for _, dd := range d.Dependencies {
+			arrow := "-->"
+			if dd.Optional {
+				arrow = "-.->"
+			}
+			_, _ = fmt.Fprintf(sb, "\t%s %s %s\n", id, arrow, getID(dd))
+		}
+	})
+	return sb.String()
+}
+
+// GetDependencyDAG returns the dependency graph of all the services of the [Container], as a directed acyclic graph.
+//
+// It initializes the services.
+// See [Container.GetDependencyGraph] and [DependencyDAG].
+func (c *Container) GetDependencyDAG(ctx context.Context) (nodes []DAGNode, edges []DAGEdge, err error) {
+	deps, err := c.GetDependencyGraph(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	nodes, edges = DependencyDAG(deps)
+	return nodes, edges, nil
+}
+
+// DAGNode is a node of the graph returned by [DependencyDAG].
+type DAGNode struct {
+	Key Key
+}
+
+// DAGEdge is an edge of the graph returned by [DependencyDAG], from a service to its dependency.
+//
+// From and To are indexes of the nodes.
+type DAGEdge struct {
+	From     int
+	To       int
+	Optional bool
+}
+
+// DependencyDAG returns the [Dependency] trees as a directed acyclic graph.
+//
+// Each unique service appears once in the nodes, and each unique dependency appears once in the edges.
+// An edge is optional only if all the occurrences of the dependency are optional.
+func DependencyDAG(deps []*Dependency) (nodes []DAGNode, edges []DAGEdge) {
+	nodeIndexes := make(map[Key]int)
+	getNodeIndex := func(d *Dependency) int {
+		k := d.exportedKey()
+		i, ok := nodeIndexes[k]
+		if !ok {
+			i = len(nodes)
+			nodeIndexes[k] = i
+			nodes = append(nodes, DAGNode{Key: k})
+		}
+		return i
+	}
+	edgeIndexes := make(map[[2]int]int)
+	walkDependencyGraph(deps, func(d *Dependency) {
+		from := getNodeIndex(d)
+		for _, dd := range d.Dependencies {
+			e := [2]int{from, getNodeIndex(dd)}
+			i, ok := edgeIndexes[e]
+			if !ok {
+				edgeIndexes[e] = len(edges)
+				edges = append(edges, DAGEdge{From: e[0], To: e[1], Optional: dd.Optional})
+				continue
+			}
+			edges[i].Optional = edges[i].Optional && dd.Optional
+		}
+	})
+	return nodes, edges
+}
+
+// mermaidEscaper escapes the characters that are not allowed in a quoted Mermaid label, with entity codes.
+var mermaidEscaper = strings.NewReplacer(
+	"#", "#35;",
+	`"`, "#quot;",
+	"<", "#lt;",
+	">", "#gt;",
+)
+
+// walkDependencyGraph calls f once for each unique [Dependency] of the trees, depth-first.
+func walkDependencyGraph(deps []*Dependency, f func(d *Dependency)) {
+	visited := make(map[string]bool)
+	for _, dep := range deps {
+		dep.walk(func(d *Dependency) bool {
+			k := d.graphKey()
+			if visited[k] {
+				return false
+			}
+			visited[k] = true
+			f(d)
+			return true
+		})
+	}
+}
+
+// graphKey returns the identifier of the [Dependency] in a graph.
+//
+// It is based on the exported fields, so it works with a [Dependency] that was decoded.
+func (d *Dependency) graphKey() string {
+	return d.exportedKey().String()
+}
+
+// exportedKey returns the [Key] of the [Dependency], based on the exported fields.
+func (d *Dependency) exportedKey() Key {
+	return Key{
+		Type:    d.Type,
+		Name:    d.Name,
+		Version: d.Version,
+	}
+}
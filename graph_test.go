@@ -0,0 +1,176 @@
+package di
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func newTestDependencyGraph(tb testing.TB) []*Dependency {
+	tb.Helper()
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "b")
+		MustGet[*int](ctx, ctn, "")
+		return "", nil, nil
+	})
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[*int](ctx, ctn, "")
+		return "", nil, nil
+	})
+	MustSetValue(ctn, "", new(int))
+	deps, err := ctn.GetDependencyGraph(ctx)
+	assert.NoError(tb, err)
+	return deps
+}
+
+func TestDependencyWriteDOT(t *testing.T) {
+	deps := newTestDependencyGraph(t)
+	buf := new(bytes.Buffer)
+	err := deps[1].WriteDOT(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, buf.String(), `digraph {
+	"string(a)";
+	"string(a)" -> "string(b)";
+	"string(a)" -> "*int";
+	"string(b)";
+	"string(b)" -> "*int";
+	"*int";
+}
+`)
+}
+
+func TestDependencyDOT(t *testing.T) {
+	deps := newTestDependencyGraph(t)
+	s := DependencyDOT(deps)
+	assert.Equal(t, s, `digraph {
+	"*int";
+	"string(a)";
+	"string(a)" -> "string(b)";
+	"string(a)" -> "*int";
+	"string(b)";
+	"string(b)" -> "*int";
+}
+`)
+}
+
+func TestDependencyWriteMermaid(t *testing.T) {
+	deps := newTestDependencyGraph(t)
+	buf := new(bytes.Buffer)
+	err := deps[1].WriteMermaid(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, buf.String(), `graph TD
+	n0["string(a)"]
+	n0 --> n1
+	n0 --> n2
+	n1["string(b)"]
+	n1 --> n2
+	n2["*int"]
+`)
+}
+
+func TestDependencyMermaid(t *testing.T) {
+	deps := newTestDependencyGraph(t)
+	s := DependencyMermaid(deps)
+	assert.Equal(t, s, `graph TD
+	n0["*int"]
+	n1["string(a)"]
+	n1 --> n2
+	n1 --> n0
+	n2["string(b)"]
+	n2 --> n0
+`)
+}
+
+func TestDependencyMermaidEscape(t *testing.T) {
+	dep := &Dependency{
+		Type: `map[string]chan<- "x"#`,
+	}
+	s := DependencyMermaid([]*Dependency{dep})
+	assert.Equal(t, s, `graph TD
+	n0["map[string]chan#lt;- #quot;x#quot;#35;"]
+`)
+}
+
+func TestDependencyGraphOptional(t *testing.T) {
+	dep := &Dependency{
+		Type: "string",
+		Name: "a",
+		Dependencies: []*Dependency{
+			{Type: "string", Name: "b", Optional: true},
+		},
+	}
+	assert.Equal(t, DependencyDOT([]*Dependency{dep}), `digraph {
+	"string(a)";
+	"string(a)" -> "string(b)" [style=dashed];
+	"string(b)";
+}
+`)
+	assert.Equal(t, DependencyMermaid([]*Dependency{dep}), `graph TD
+	n0["string(a)"]
+	n0 -.-> n1
+	n1["string(b)"]
+`)
+}
+
+func TestDependencyDAG(t *testing.T) {
+	deps := newTestDependencyGraph(t)
+	nodes, edges := DependencyDAG(deps)
+	assert.DeepEqual(t, nodes, []DAGNode{
+		{Key: Key{Type: "*int"}},
+		{Key: Key{Type: "string", Name: "a"}},
+		{Key: Key{Type: "string", Name: "b"}},
+	})
+	assert.DeepEqual(t, edges, []DAGEdge{
+		{From: 1, To: 2},
+		{From: 1, To: 0},
+		{From: 2, To: 0},
+	})
+}
+
+func TestDependencyDAGOptional(t *testing.T) {
+	dep := &Dependency{
+		Type: "string",
+		Name: "a",
+		Dependencies: []*Dependency{
+			{Type: "string", Name: "b", Optional: true},
+			{Type: "string", Name: "c", Optional: true},
+			{Type: "string", Name: "c"},
+		},
+	}
+	_, edges := DependencyDAG([]*Dependency{dep})
+	assert.DeepEqual(t, edges, []DAGEdge{
+		{From: 0, To: 1, Optional: true},
+		{From: 0, To: 2},
+	})
+}
+
+func TestContainerGetDependencyDAG(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "b")
+		return "", nil, nil
+	})
+	MustSetValue(ctn, "b", "")
+	nodes, edges, err := ctn.GetDependencyDAG(ctx)
+	assert.NoError(t, err)
+	assert.SliceLen(t, nodes, 2)
+	assert.DeepEqual(t, edges, []DAGEdge{
+		{From: 0, To: 1},
+	})
+}
+
+func TestContainerGetDependencyDAGError(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, errors.New("error")
+	})
+	_, _, err := ctn.GetDependencyDAG(ctx)
+	assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package di
+
+import (
+	"context"
+	"errors"
+)
+
+// HealthChecker is implemented by services that can report their health.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// CheckHealth checks the health of the initialized services of the [Container] that implement [HealthChecker].
+//
+// The services that are not initialized are skipped: they are not built.
+// Factory services are skipped.
+//
+// The errors of all services are joined.
+func (c *Container) CheckHealth(ctx context.Context) error {
+	var errs []error
+	for _, sw := range c.getServiceWrappersInitialize() {
+		s, ok, err := sw.getIfInitialized(ctx)
+		if err != nil {
+			errs = append(errs, c.wrapServiceError(err, sw.key))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		hc, ok := s.(HealthChecker)
+		if !ok {
+			continue
+		}
+		err = hc.CheckHealth(ctx)
+		if err != nil {
+			errs = append(errs, c.wrapServiceError(err, sw.key))
+		}
+	}
+	return errors.Join(errs...)
+}
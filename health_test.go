@@ -0,0 +1,67 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+type testHealthChecker struct {
+	err error
+}
+
+func (hc *testHealthChecker) CheckHealth(ctx context.Context) error {
+	return hc.err
+}
+
+func TestContainerCheckHealth(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "ok", &testHealthChecker{})
+	MustSetValue(ctn, "error", &testHealthChecker{err: errors.New("error")})
+	builderCalled := false
+	MustSet(ctn, "not_initialized", func(ctx context.Context, ctn *Container) (*testHealthChecker, Close, error) {
+		builderCalled = true
+		return &testHealthChecker{err: errors.New("error")}, nil, nil
+	})
+	MustSetValue(ctn, "", "not_checker")
+	err := ctn.CheckHealth(ctx)
+	assert.NoError(t, err)
+	MustGet[*testHealthChecker](ctx, ctn, "ok")
+	MustGet[*testHealthChecker](ctx, ctn, "error")
+	MustGet[string](ctx, ctn, "")
+	err = ctn.CheckHealth(ctx)
+	var serviceErr *ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+	assert.Equal(t, serviceErr.Key, newKey[*testHealthChecker]("error"))
+	assert.ErrorEqual(t, err, "service *github.com/pierrre/di.testHealthChecker(error): error")
+	assert.False(t, builderCalled)
+}
+
+func TestContainerCheckHealthErrorContextCanceled(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	started := make(chan struct{})
+	block := make(chan struct{})
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		close(started)
+		<-block
+		return "", nil, nil
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		MustGet[string](ctx, ctn, "")
+	}()
+	defer func() {
+		close(block)
+		<-done
+	}()
+	<-started
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	err := ctn.CheckHealth(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,129 @@
+package di
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/pierrre/go-libs/goroutine"
+)
+
+// Initialize initializes all the services of the [Container].
+//
+// It allows to detect errors at startup, instead of the first [Get].
+// Factory services are not initialized.
+//
+// The errors of all services are joined.
+// The errors of the optional services are logged and ignored, see [WithOptional].
+func (c *Container) Initialize(ctx context.Context) error {
+	var errs []error
+	for _, sw := range c.getServiceWrappersInitialize() {
+		err := c.initializeService(ctx, sw)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// initializeService initializes a service.
+// If the service is optional, the error is logged and ignored.
+func (c *Container) initializeService(ctx context.Context, sw *serviceWrapper) error {
+	_, err := c.get(ctx, sw.key)
+	if err != nil && sw.optional {
+		c.logOptionalBuildFailed(ctx, sw.key, err)
+		return nil
+	}
+	return err
+}
+
+// InitializeParallel initializes all the services of the [Container] concurrently, with at most maxWorkers goroutines.
+//
+// The services are not sorted by dependencies: each goroutine starts building its service immediately.
+// If a [Builder] gets a dependency that is being built by another goroutine, it waits for it, so each [Builder] is called once.
+// A waiting goroutine still counts in maxWorkers.
+// A cycle between services built by different goroutines is detected before waiting, and returned as a [CycleError].
+//
+// The optional services are initialized sequentially afterward, unless they are a dependency of another service.
+//
+// See [Container.Initialize].
+func (c *Container) InitializeParallel(ctx context.Context, maxWorkers int) error {
+	var keys []Key
+	var optionals []*serviceWrapper
+	for _, sw := range c.getServiceWrappersInitialize() {
+		if sw.optional {
+			optionals = append(optionals, sw)
+		} else {
+			keys = append(keys, sw.key)
+		}
+	}
+	err := c.initializeParallel(ctx, keys, maxWorkers)
+	for _, sw := range optionals {
+		_ = c.initializeService(ctx, sw) // The error is always nil for an optional service.
+	}
+	return err
+}
+
+func (c *Container) initializeParallel(ctx context.Context, keys []Key, maxWorkers int) error {
+	_, err := c.getParallel(ctx, keys, maxWorkers)
+	return err
+}
+
+// getParallel gets the services concurrently, and returns their values in the same order as the keys.
+//
+// The goroutines wait for the services built by the other goroutines, so each [Builder] is called once.
+// The cycles between goroutines are detected by the wait graph, see [setParallelBuildToContext].
+func (c *Container) getParallel(ctx context.Context, keys []Key, maxWorkers int) ([]any, error) {
+	values := make([]any, len(keys))
+	errs := goroutine.Slice(setParallelBuildToContext(ctx), keys, max(maxWorkers, 1), func(ctx context.Context, i int, key Key) error {
+		v, err := c.get(ctx, key)
+		if err != nil {
+			return err
+		}
+		values[i] = v // Each goroutine writes a different index.
+		return nil
+	})
+	err := ctx.Err()
+	if err != nil {
+		// Some services were possibly not processed.
+		return values, err
+	}
+	return values, errors.Join(errs...)
+}
+
+// InitializationOrder returns the keys of the initialized services, in the order their [Builder] completed.
+//
+// For a factory service, the last build is used.
+func (c *Container) InitializationOrder() []Key {
+	type keySequence struct {
+		key      Key
+		sequence int64
+	}
+	var kss []keySequence
+	c.all(func(key Key, sw *serviceWrapper) {
+		seq := sw.sequence.Load()
+		if seq != 0 {
+			kss = append(kss, keySequence{key: key, sequence: seq})
+		}
+	})
+	slices.SortFunc(kss, func(a, b keySequence) int {
+		return cmp.Compare(a.sequence, b.sequence)
+	})
+	keys := make([]Key, len(kss))
+	for i, ks := range kss {
+		keys[i] = ks.key
+	}
+	return keys
+}
+
+func (c *Container) getServiceWrappersInitialize() []*serviceWrapper {
+	sws := c.services.getValues()
+	sws = slices.DeleteFunc(sws, func(sw *serviceWrapper) bool {
+		return sw.factory
+	})
+	slices.SortFunc(sws, func(a, b *serviceWrapper) int {
+		return cmp.Compare(a.key.String(), b.key.String())
+	})
+	return sws
+}
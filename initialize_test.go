@@ -0,0 +1,221 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func TestContainerInitialize(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	builderCalled := 0
+	for _, name := range []string{"a", "b", "c"} {
+		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			builderCalled++
+			return name, nil, nil
+		})
+	}
+	MustSetFactory(ctn, "", func(ctx context.Context, ctn *Container) (int, Close, error) {
+		return 0, nil, errors.New("error")
+	})
+	err := ctn.Initialize(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, builderCalled, 3)
+	MustGet[string](ctx, ctn, "a")
+	assert.Equal(t, builderCalled, 3)
+}
+
+func TestContainerInitializeError(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	for _, name := range []string{"a", "b"} {
+		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "", nil, errors.New("error")
+		})
+	}
+	err := ctn.Initialize(ctx)
+	var serviceErr *ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+	assert.ErrorEqual(t, err, "service string(a): error\nservice string(b): error")
+}
+
+func TestContainerInitializeErrorCycle(t *testing.T) {
+	ctx := context.Background()
+	ctn := newTestContainerCycle()
+	err := ctn.Initialize(ctx)
+	assert.ErrorIs(t, err, ErrCycle)
+}
+
+func TestContainerInitializeParallel(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var current, maxCurrent atomic.Int64
+	leaves := []string{"a", "b", "c", "d"}
+	for _, name := range leaves {
+		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			c := current.Add(1)
+			defer current.Add(-1)
+			for {
+				m := maxCurrent.Load()
+				if c <= m || maxCurrent.CompareAndSwap(m, c) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return name, nil, nil
+		})
+	}
+	MustSet(ctn, "root", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		for _, name := range leaves {
+			MustGet[string](ctx, ctn, name)
+		}
+		return "root", nil, nil
+	})
+	err := ctn.InitializeParallel(ctx, len(leaves)+1)
+	assert.NoError(t, err)
+	assert.Greater(t, maxCurrent.Load(), 1)
+	dep, err := GetDependency[string](ctx, ctn, "root")
+	assert.NoError(t, err)
+	assert.SliceLen(t, dep.Dependencies, len(leaves))
+}
+
+func TestContainerInitializeParallelBuildOnce(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var builderCalled atomic.Int64
+	MustSet(ctn, "leaf", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		builderCalled.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return "leaf", nil, nil
+	})
+	for _, name := range []string{"a", "b", "c"} {
+		MustSet(ctn, name, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			builderCalled.Add(1)
+			return name + MustGet[string](ctx, ctn, "leaf"), nil, nil
+		})
+	}
+	var buildEnds atomic.Int64
+	ctn.OnEvent(func(ev Event) {
+		if ev.Type == EventBuildEnd {
+			assert.NoError(t, ev.Err)
+			buildEnds.Add(1)
+		}
+	})
+	err := ctn.InitializeParallel(ctx, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, builderCalled.Load(), 4)
+	assert.Equal(t, buildEnds.Load(), 4)
+}
+
+func TestContainerInitializeParallelBuilderGoroutines(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "leaf", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		time.Sleep(10 * time.Millisecond)
+		return "leaf", nil, nil
+	})
+	MustSet(ctn, "root", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		// The goroutines share the build chain of the root, and wait for each other without cycle.
+		errs := make(chan error, 2)
+		for range 2 {
+			go func() {
+				_, err := Get[string](ctx, ctn, "leaf")
+				errs <- err
+			}()
+		}
+		return "root", nil, errors.Join(<-errs, <-errs)
+	})
+	err := ctn.InitializeParallel(ctx, 1)
+	assert.NoError(t, err)
+}
+
+func TestContainerInitializeParallelError(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, errors.New("error")
+	})
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, nil
+	})
+	err := ctn.InitializeParallel(ctx, 2)
+	assert.ErrorEqual(t, err, "service string(a): error")
+}
+
+func TestContainerInitializeParallelErrorCycle(t *testing.T) {
+	ctx := context.Background()
+	ctn := newTestContainerCycle()
+	err := ctn.InitializeParallel(ctx, 3)
+	assert.ErrorIs(t, err, ErrCycle)
+}
+
+func TestContainerInitializeParallelErrorCycleGoroutines(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	aStarted := make(chan struct{})
+	bStarted := make(chan struct{})
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		closeOnce(aStarted)
+		<-bStarted
+		_, err := Get[string](ctx, ctn, "b")
+		return "", nil, err
+	})
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		closeOnce(bStarted)
+		<-aStarted
+		_, err := Get[string](ctx, ctn, "a")
+		return "", nil, err
+	})
+	err := ctn.InitializeParallel(ctx, 2)
+	assert.ErrorIs(t, err, ErrCycle)
+	var cycleErr *CycleError
+	assert.ErrorAs(t, err, &cycleErr)
+	assert.SliceLen(t, cycleErr.Keys, 3)
+	assert.Equal(t, cycleErr.Keys[0], cycleErr.Keys[2])
+}
+
+func closeOnce(ch chan struct{}) {
+	select {
+	case <-ch:
+	default:
+		close(ch)
+	}
+}
+
+func TestContainerInitializationOrder(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "c")
+		MustGet[string](ctx, ctn, "b")
+		return "", nil, nil
+	})
+	MustSetValue(ctn, "b", "")
+	MustSetValue(ctn, "c", "")
+	MustSetValue(ctn, "d", "")
+	MustGet[string](ctx, ctn, "a")
+	keys := ctn.InitializationOrder()
+	assert.DeepEqual(t, keys, []Key{newKey[string]("c"), newKey[string]("b"), newKey[string]("a")})
+}
+
+func TestContainerInitializationOrderParallel(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "b")
+		return "", nil, nil
+	})
+	MustSetValue(ctn, "b", "")
+	MustSetValue(ctn, "c", "")
+	err := ctn.InitializeParallel(ctx, 3)
+	assert.NoError(t, err)
+	keys := ctn.InitializationOrder()
+	assert.SliceLen(t, keys, 3)
+	assert.Less(t, slices.Index(keys, newKey[string]("b")), slices.Index(keys, newKey[string]("a")))
+}
@@ -0,0 +1,50 @@
+package di
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// WithLogger returns a [ContainerOption] that sets the [slog.Logger] of the [Container].
+//
+// It logs at debug level when a service is registered, built and closed.
+// It logs at warn level when the build of an optional service fails during the initialization, see [WithOptional].
+// By default, there is no logger.
+func WithLogger(l *slog.Logger) ContainerOption {
+	return func(cfg *containerConfig) {
+		cfg.logger = l
+	}
+}
+
+func (c *Container) logRegistered(key Key) {
+	if c.config.logger == nil {
+		return
+	}
+	c.config.logger.LogAttrs(context.Background(), slog.LevelDebug, "registered service", slog.String("key", key.String()))
+}
+
+func (c *Container) logBuilt(ctx context.Context, key Key, d time.Duration) {
+	if c.config.logger == nil {
+		return
+	}
+	c.config.logger.LogAttrs(ctx, slog.LevelDebug, "built service", slog.String("key", key.String()), slog.Duration("duration", d))
+}
+
+func (c *Container) logClosed(ctx context.Context, key Key, err error) {
+	if c.config.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{slog.String("key", key.String())}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	c.config.logger.LogAttrs(ctx, slog.LevelDebug, "closed service", attrs...)
+}
+
+func (c *Container) logOptionalBuildFailed(ctx context.Context, key Key, err error) {
+	if c.config.logger == nil {
+		return
+	}
+	c.config.logger.LogAttrs(ctx, slog.LevelWarn, "optional service build failed", slog.String("key", key.String()), slog.Any("error", err))
+}
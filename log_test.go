@@ -0,0 +1,40 @@
+package di
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithLogger(t *testing.T) {
+	ctx := context.Background()
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	ctn := NewContainer(WithLogger(logger))
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			return errors.New("error")
+		}, nil
+	})
+	MustGet[string](ctx, ctn, "")
+	_ = ctn.Close(ctx)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.DeepEqual(t, lines, []string{
+		`level=DEBUG msg="registered service" key=string`,
+		`level=DEBUG msg="built service" key=string`,
+		`level=DEBUG msg="closed service" key=string error=error`,
+	})
+}
@@ -0,0 +1,31 @@
+package di
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Manifest is the list of the services set to a [Container], sorted by [Key.String].
+//
+// It can be written as JSON, and compared between environments in order to detect a configuration drift.
+type Manifest []Key
+
+// Manifest returns the [Manifest] of the [Container].
+//
+// It doesn't initialize the services.
+// The services of the parents are not included.
+func (c *Container) Manifest() Manifest {
+	return Manifest(c.Keys())
+}
+
+// WriteJSON writes the [Manifest] to a [io.Writer], as indented JSON.
+//
+// The output is deterministic, so it can be compared with a text diff.
+func (m Manifest) WriteJSON(w io.Writer) error {
+	if m == nil {
+		m = Manifest{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(m) //nolint:wrapcheck // We don't need to wrap.
+}
@@ -0,0 +1,49 @@
+package di
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestManifest(t *testing.T) {
+	ctn := new(Container)
+	MustSetValue(ctn, "b", "b")
+	MustSetValue(ctn, "a", "a")
+	MustSetValue(ctn, "", 1)
+	MustSetVersioned(ctn, "", 2, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "v2", nil, nil
+	})
+	m := ctn.Manifest()
+	buf := new(bytes.Buffer)
+	err := m.WriteJSON(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, buf.String(), `[
+	{
+		"type": "int"
+	},
+	{
+		"type": "string",
+		"name": "a"
+	},
+	{
+		"type": "string",
+		"name": "b"
+	},
+	{
+		"type": "string",
+		"version": 2
+	}
+]
+`)
+}
+
+func TestManifestEmpty(t *testing.T) {
+	ctn := new(Container)
+	buf := new(bytes.Buffer)
+	err := ctn.Manifest().WriteJSON(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, buf.String(), "[]\n")
+}
@@ -0,0 +1,54 @@
+package di
+
+import (
+	"context"
+	"sync"
+)
+
+// BuildFunc builds a service.
+//
+// It is the untyped version of [Builder], see [BuilderMiddleware].
+type BuildFunc func(ctx context.Context, ctn *Container) (any, Close, error)
+
+// BuilderMiddleware wraps the build of a service.
+//
+// It must call next with a [context.Context] derived from the given one, in order to keep the cycle detection and the dependency collection working.
+// A panic is recovered as a [PanicError], and the service timeout applies (see [WithBuildTimeout]).
+//
+// See [Container.Use].
+type BuilderMiddleware func(key Key, next BuildFunc) BuildFunc
+
+// Use registers a [BuilderMiddleware] to the [Container].
+//
+// It is applied to the builds of all the services set to the [Container], including the factory services.
+// The middlewares are applied in the registration order: the first registered is the outermost.
+// It only applies to the builds that start after the call.
+func (c *Container) Use(mw BuilderMiddleware) {
+	c.middlewares.add(mw)
+}
+
+// applyMiddlewares returns the [BuildFunc] of the service, wrapped by the middlewares of the [Container].
+func (c *Container) applyMiddlewares(key Key, b BuildFunc) BuildFunc {
+	mws := c.middlewares.get()
+	for i := len(mws) - 1; i >= 0; i-- {
+		b = mws[i](key, b)
+	}
+	return b
+}
+
+type builderMiddlewares struct {
+	mu  sync.Mutex
+	mws []BuilderMiddleware
+}
+
+func (bms *builderMiddlewares) add(mw BuilderMiddleware) {
+	bms.mu.Lock()
+	defer bms.mu.Unlock()
+	bms.mws = append(bms.mws, mw)
+}
+
+func (bms *builderMiddlewares) get() []BuilderMiddleware {
+	bms.mu.Lock()
+	defer bms.mu.Unlock()
+	return bms.mws[:len(bms.mws):len(bms.mws)]
+}
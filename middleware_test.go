@@ -0,0 +1,88 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+type testMiddlewareContextKey struct{}
+
+func TestContainerUse(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var calls []string
+	for _, name := range []string{"first", "second"} {
+		ctn.Use(func(key Key, next BuildFunc) BuildFunc {
+			return func(ctx context.Context, ctn *Container) (any, Close, error) {
+				calls = append(calls, name+" start "+key.Name)
+				ctx = context.WithValue(ctx, testMiddlewareContextKey{}, name)
+				s, cl, err := next(ctx, ctn)
+				calls = append(calls, name+" end "+key.Name)
+				return s, cl, err
+			}
+		})
+	}
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return MustGet[string](ctx, ctn, "b"), nil, nil
+	})
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		v, _ := ctx.Value(testMiddlewareContextKey{}).(string)
+		return v, nil, nil
+	})
+	s := MustGet[string](ctx, ctn, "a")
+	assert.Equal(t, s, "second")
+	assert.DeepEqual(t, calls, []string{
+		"first start a",
+		"second start a",
+		"first start b",
+		"second start b",
+		"second end b",
+		"first end b",
+		"second end a",
+		"first end a",
+	})
+	dep := MustGetDependency[string](ctx, ctn, "a")
+	assert.SliceLen(t, dep.Dependencies, 1)
+}
+
+func TestContainerUseErrorCycle(t *testing.T) {
+	ctx := context.Background()
+	ctn := newTestContainerCycle()
+	ctn.Use(func(key Key, next BuildFunc) BuildFunc {
+		return func(ctx context.Context, ctn *Container) (any, Close, error) {
+			return next(context.WithValue(ctx, testMiddlewareContextKey{}, ""), ctn)
+		}
+	})
+	_, err := Get[string](ctx, ctn, "a")
+	assert.ErrorIs(t, err, ErrCycle)
+}
+
+func TestContainerUseError(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	ctn.Use(func(key Key, next BuildFunc) BuildFunc {
+		return func(ctx context.Context, ctn *Container) (any, Close, error) {
+			return nil, nil, errors.New("error")
+		}
+	})
+	MustSetValue(ctn, "", "test")
+	_, err := Get[string](ctx, ctn, "")
+	assert.ErrorEqual(t, err, "service string: error")
+}
+
+func TestContainerUsePanic(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	ctn.Use(func(key Key, next BuildFunc) BuildFunc {
+		return func(ctx context.Context, ctn *Container) (any, Close, error) {
+			panic("test")
+		}
+	})
+	MustSetValue(ctn, "", "test")
+	_, err := Get[string](ctx, ctn, "")
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr)
+}
@@ -0,0 +1,38 @@
+package di
+
+import (
+	"fmt"
+)
+
+// Module sets a group of services to a [Container].
+//
+// It allows to compose reusable bundles of services.
+type Module func(ctn *Container) error
+
+// Apply applies the [Module]s to the [Container], in order.
+//
+// It stops at the first error, and returns it with the index of the [Module].
+func (c *Container) Apply(modules ...Module) error {
+	for i, m := range modules {
+		err := m(c)
+		if err != nil {
+			return fmt.Errorf("module %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// MustApply calls [Container.Apply] and panics if there is an error.
+func (c *Container) MustApply(modules ...Module) {
+	err := c.Apply(modules...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CombineModules returns a [Module] that applies the [Module]s in order.
+func CombineModules(modules ...Module) Module {
+	return func(ctn *Container) error {
+		return ctn.Apply(modules...)
+	}
+}
@@ -0,0 +1,63 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestContainerApply(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	moduleA := func(ctn *Container) error {
+		return SetValue(ctn, "a", "a")
+	}
+	moduleB := func(ctn *Container) error {
+		return SetValue(ctn, "b", "b")
+	}
+	moduleC := func(ctn *Container) error {
+		return SetValue(ctn, "c", "c")
+	}
+	err := ctn.Apply(moduleA, CombineModules(moduleB, moduleC))
+	assert.NoError(t, err)
+	ss, err := GetAll[string](ctx, ctn)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, ss, map[string]string{"a": "a", "b": "b", "c": "c"})
+}
+
+func TestContainerApplyError(t *testing.T) {
+	ctn := new(Container)
+	called := false
+	err := ctn.Apply(
+		func(ctn *Container) error {
+			return nil
+		},
+		CombineModules(
+			func(ctn *Container) error {
+				return nil
+			},
+			func(ctn *Container) error {
+				return errors.New("error")
+			},
+		),
+		func(ctn *Container) error {
+			called = true
+			return nil
+		},
+	)
+	assert.ErrorEqual(t, err, "module 1: module 1: error")
+	assert.False(t, called)
+}
+
+func TestContainerMustApplyPanic(t *testing.T) {
+	ctn := new(Container)
+	module := func(ctn *Container) error {
+		return SetValue(ctn, "", "")
+	}
+	ctn.MustApply(module)
+	assert.Panics(t, func() {
+		ctn.MustApply(module)
+	})
+}
@@ -2,34 +2,81 @@ package di
 
 import (
 	"context"
+	"slices"
+	"sync"
 )
 
 type mutex struct {
-	ch chan struct{}
+	ch  chan struct{}
+	key Key
 }
 
-func newMutex() *mutex {
+func newMutex(key Key) *mutex {
 	return &mutex{
-		ch: make(chan struct{}, 1),
+		ch:  make(chan struct{}, 1),
+		key: key,
 	}
 }
 
-func (m *mutex) lock(ctx context.Context) (context.Context, error) {
-	previous, _ := ctx.Value(mutexListContextKey{}).(*mutexList)
-	for v := previous; v != nil; v = v.previous {
-		if v.mu == m {
-			return nil, ErrCycle
+// lock locks the mutex, and returns a [context.Context] that contains the [buildState] of the locked mutex.
+//
+// If maxDepth is greater than 0, it is the maximum number of locked mutexes.
+func (m *mutex) lock(ctx context.Context, maxDepth int) (context.Context, error) {
+	previous := getBuildState(ctx)
+	depth := 1
+	if previous != nil {
+		depth = previous.depth + 1
+		for v := previous; v != nil; v = v.previous {
+			if v.mu == m {
+				return nil, newCycleError(previous, v, m.key)
+			}
+		}
+	}
+	if maxDepth > 0 && depth > maxDepth {
+		return nil, ErrMaxDepthExceeded
+	}
+	err := m.acquire(ctx, previous)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withBuildState(ctx, buildState{
+		previous: previous,
+		mu:       m,
+		depth:    depth,
+	})
+	return ctx, nil
+}
+
+// acquire acquires the mutex.
+//
+// In a parallel build, the wait is registered in the wait graph, so a deadlock between the goroutines is returned as a [CycleError].
+func (m *mutex) acquire(ctx context.Context, top *buildState) error {
+	if m.tryAcquire() {
+		return nil
+	}
+	// A build that doesn't hold any mutex can't be part of a deadlock.
+	if top != nil && isParallelBuild(ctx) {
+		wt, err := startWait(m, top)
+		if err != nil {
+			return err
 		}
+		defer stopWait(wt)
 	}
 	select {
 	case m.ch <- struct{}{}:
-		ctx = context.WithValue(ctx, mutexListContextKey{}, &mutexList{
-			previous: previous,
-			mu:       m,
-		})
-		return ctx, nil
+		return nil
 	case <-ctx.Done():
-		return nil, ctx.Err() //nolint:wrapcheck // We don't neet to wrap.
+		return ctx.Err() //nolint:wrapcheck // We don't neet to wrap.
+	}
+}
+
+// tryAcquire acquires the mutex if it is not locked, and returns true if it succeeded.
+func (m *mutex) tryAcquire() bool {
+	select {
+	case m.ch <- struct{}{}:
+		return true
+	default:
+		return false
 	}
 }
 
@@ -37,9 +84,154 @@ func (m *mutex) unlock() {
 	<-m.ch
 }
 
-type mutexList struct {
-	previous *mutexList
-	mu       *mutex
+// buildState is the state of a locked mutex, stored in the [context.Context].
+//
+// It contains the list of locked mutexes, used to detect cycles, and the dependency collector of the current build.
+type buildState struct {
+	previous  *buildState
+	mu        *mutex
+	depth     int
+	collector *dependencyCollector
+}
+
+type buildStateContextKey struct{}
+
+// buildContext is a [context.Context] that contains a [buildState].
+//
+// It avoids to create a new [context.Context] layer for each locked mutex, see [withBuildState].
+type buildContext struct {
+	context.Context //nolint:containedctx // It is a context wrapper.
+	state           buildState
+}
+
+func (c *buildContext) Value(key any) any {
+	if key == (buildStateContextKey{}) {
+		return &c.state
+	}
+	return c.Context.Value(key)
+}
+
+// withBuildState returns a [context.Context] that contains the [buildState].
+//
+// If the [context.Context] is already a [buildContext] (the [Builder] didn't add anything to it), it is replaced instead of wrapped.
+// So the length of the [context.Context] chain doesn't grow with the depth of the build.
+func withBuildState(ctx context.Context, st buildState) context.Context {
+	bc, ok := ctx.(*buildContext)
+	if ok {
+		ctx = bc.Context
+	}
+	return &buildContext{
+		Context: ctx,
+		state:   st,
+	}
+}
+
+func getBuildState(ctx context.Context) *buildState {
+	st, _ := ctx.Value(buildStateContextKey{}).(*buildState)
+	return st
+}
+
+// newCycleError returns a [CycleError] for the mutexes locked from start to end (inclusive), followed by the key that is locked again.
+func newCycleError(end, start *buildState, key Key) *CycleError {
+	return &CycleError{
+		Keys: append(getBuildChainKeys(end, start.mu), key),
+	}
+}
+
+type parallelBuildContextKey struct{}
+
+// setParallelBuildToContext enables the wait graph of the parallel builds, see [Container.getParallel].
+func setParallelBuildToContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, parallelBuildContextKey{}, true)
+}
+
+func isParallelBuild(ctx context.Context) bool {
+	v, _ := ctx.Value(parallelBuildContextKey{}).(bool)
+	return v
+}
+
+// The wait graph of the parallel builds.
+//
+// The goroutines of a parallel build wait for the mutexes held by the other goroutines, so each [Builder] is called once.
+// A cycle between several goroutines would deadlock, and can't be detected with the build chain of a single goroutine.
+// So, before waiting, the build follows the waiting builds that hold the mutex, and the mutexes that they wait for.
+// If it comes back to a mutex held by its own build chain, it returns a [CycleError] instead of waiting.
+// A mutex is held by the build chains that contain it: the build that locked it, or the goroutines started by its [Builder].
+var (
+	waitGraphMu sync.Mutex
+	waits       = make(map[*wait]struct{})
+)
+
+// wait is a build, identified by the top of its build chain, that waits for a mutex.
+type wait struct {
+	top *buildState
+	mu  *mutex
+}
+
+func startWait(m *mutex, top *buildState) (*wait, error) {
+	waitGraphMu.Lock()
+	defer waitGraphMu.Unlock()
+	keys := findWaitCycle(m, top, nil)
+	if keys != nil {
+		return nil, &CycleError{
+			Keys: append(keys, m.key),
+		}
+	}
+	wt := &wait{
+		top: top,
+		mu:  m,
+	}
+	waits[wt] = struct{}{}
+	return wt, nil
+}
+
+func stopWait(wt *wait) {
+	waitGraphMu.Lock()
+	defer waitGraphMu.Unlock()
+	delete(waits, wt)
+}
+
+// findWaitCycle returns the keys of the cycle, if waiting for the mutex would deadlock the build chain top.
+//
+// It must be called with waitGraphMu locked.
+func findWaitCycle(m *mutex, top *buildState, visited []*mutex) []Key {
+	if isInBuildChain(top, m) {
+		return getBuildChainKeys(top, m)
+	}
+	if slices.Contains(visited, m) {
+		return nil
+	}
+	visited = append(visited, m)
+	for wt := range waits {
+		if !isInBuildChain(wt.top, m) {
+			continue
+		}
+		keys := findWaitCycle(wt.mu, top, visited)
+		if keys != nil {
+			return append(getBuildChainKeys(wt.top, m), keys...)
+		}
+	}
+	return nil
 }
 
-type mutexListContextKey struct{}
+func isInBuildChain(top *buildState, m *mutex) bool {
+	for v := top; v != nil; v = v.previous {
+		if v.mu == m {
+			return true
+		}
+	}
+	return false
+}
+
+// getBuildChainKeys returns the keys of the build chain, from the mutex to the top.
+func getBuildChainKeys(top *buildState, m *mutex) []Key {
+	var keys []Key
+	for v := top; v != nil; v = v.previous {
+		keys = append(keys, v.mu.key)
+		if v.mu == m {
+			break
+		}
+	}
+	slices.Reverse(keys)
+	return keys
+}
@@ -14,15 +14,29 @@ func BenchmarkMutex(b *testing.B) {
 			ctx := context.Background()
 			var err error
 			for range n {
-				ctx, err = newMutex().lock(ctx)
+				ctx, err = newMutex(Key{}).lock(ctx, 0)
 				assert.NoError(b, err)
 			}
 			b.ResetTimer()
-			mu := newMutex()
+			mu := newMutex(Key{})
 			for range b.N {
-				_, _ = mu.lock(ctx)
+				_, _ = mu.lock(ctx, 0)
 				mu.unlock()
 			}
 		})
 	}
 }
+
+func TestMutexLockContextChain(t *testing.T) {
+	ctx := context.Background()
+	lockCtx, err := newMutex(Key{}).lock(ctx, 0)
+	assert.NoError(t, err)
+	for range 10 {
+		lockCtx, err = newMutex(Key{}).lock(lockCtx, 0)
+		assert.NoError(t, err)
+	}
+	bc, ok := lockCtx.(*buildContext)
+	assert.True(t, ok)
+	assert.Equal(t, bc.Context, ctx)
+	assert.Equal(t, bc.state.depth, 11)
+}
@@ -0,0 +1,46 @@
+package di
+
+import (
+	"context"
+	"strings"
+)
+
+// NamespaceSeparator separates the namespace and the name of a service, see [Namespaced].
+const NamespaceSeparator = "/"
+
+// Namespaced returns the name of a service in a namespace, e.g. the name of a module.
+//
+// The namespace and the name are separated by [NamespaceSeparator].
+// In the namespace, [NamespaceSeparator] and "\" are escaped with "\", so the namespace always ends at the first unescaped separator.
+// The name is kept as is, so namespaces can be nested in the name: Namespaced("a", Namespaced("b", "c")) returns "a/b/c".
+// Nesting in the namespace escapes the inner separator: Namespaced(Namespaced("a", "b"), "c") returns `a\/b/c`.
+//
+// It allows independent modules to set services of the same type and name without collision.
+func Namespaced(namespace, name string) string {
+	return namespaceEscaper.Replace(namespace) + NamespaceSeparator + name
+}
+
+var namespaceEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	NamespaceSeparator, `\`+NamespaceSeparator,
+)
+
+// SetNamespaced calls [Set] with a [Namespaced] name.
+func SetNamespaced[S any](ctn *Container, namespace, name string, b Builder[S], opts ...ServiceOption) error {
+	return Set(ctn, Namespaced(namespace, name), b, opts...)
+}
+
+// MustSetNamespaced calls [SetNamespaced] and panics if there is an error.
+func MustSetNamespaced[S any](ctn *Container, namespace, name string, b Builder[S], opts ...ServiceOption) {
+	MustSet(ctn, Namespaced(namespace, name), b, opts...)
+}
+
+// GetNamespaced calls [Get] with a [Namespaced] name.
+func GetNamespaced[S any](ctx context.Context, ctn Resolver, namespace, name string) (S, error) {
+	return Get[S](ctx, ctn, Namespaced(namespace, name))
+}
+
+// MustGetNamespaced calls [GetNamespaced] and panics if there is an error.
+func MustGetNamespaced[S any](ctx context.Context, ctn Resolver, namespace, name string) S {
+	return MustGet[S](ctx, ctn, Namespaced(namespace, name))
+}
@@ -0,0 +1,46 @@
+package di
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestNamespaced(t *testing.T) {
+	for _, tc := range []struct {
+		namespace string
+		name      string
+		expected  string
+	}{
+		{namespace: "a", name: "b", expected: `a/b`},
+		{namespace: "a", name: "", expected: `a/`},
+		{namespace: "a/b", name: "c", expected: `a\/b/c`},
+		{namespace: `a\`, name: "b", expected: `a\\/b`},
+		{namespace: Namespaced("a", "b"), name: "c", expected: `a\/b/c`},
+		{namespace: "a", name: Namespaced("b", "c"), expected: `a/b/c`},
+	} {
+		assert.Equal(t, Namespaced(tc.namespace, tc.name), tc.expected)
+	}
+	assert.NotEqual(t, Namespaced("a/b", "c"), Namespaced("a", "b/c"))
+}
+
+func TestSetNamespaced(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	for _, namespace := range []string{"a", "b"} {
+		err := SetNamespaced(ctn, namespace, "dsn", func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return namespace, nil, nil
+		})
+		assert.NoError(t, err)
+	}
+	s, err := GetNamespaced[string](ctx, ctn, "a", "dsn")
+	assert.NoError(t, err)
+	assert.Equal(t, s, "a")
+	s = MustGetNamespaced[string](ctx, ctn, "b", "dsn")
+	assert.Equal(t, s, "b")
+	assert.Equal(t, newKey[string](Namespaced("a", "dsn")).String(), "string(a/dsn)")
+	assert.Panics(t, func() {
+		MustSetNamespaced(ctn, "a", "dsn", newValueBuilder(""))
+	})
+}
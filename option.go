@@ -0,0 +1,96 @@
+package di
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+)
+
+// NewContainer returns a new [Container] with options.
+func NewContainer(opts ...ContainerOption) *Container {
+	c := new(Container)
+	for _, opt := range opts {
+		opt(&c.config)
+	}
+	return c
+}
+
+// ContainerOption represents an option for a [Container].
+type ContainerOption func(cfg *containerConfig)
+
+type containerConfig struct {
+	typeNamer     func(typ reflect.Type) string
+	errorWrapper  ErrorWrapper
+	closeTracking bool
+	maxDepth      int
+	buildHook     BuildHook
+	logger        *slog.Logger
+
+	dependencyTrackingDisabled bool
+	strictDependencies         bool
+}
+
+// WithTypeNamer returns a [ContainerOption] that sets the function used to name the types in [Dependency.Type].
+//
+// By default, the full name of the type is used, as in [Key.Type].
+func WithTypeNamer(f func(typ reflect.Type) string) ContainerOption {
+	return func(cfg *containerConfig) {
+		cfg.typeNamer = f
+	}
+}
+
+// WithErrorWrapper returns a [ContainerOption] that sets the [ErrorWrapper] used to wrap the errors related to a service.
+//
+// By default, the errors are wrapped in a [ServiceError].
+func WithErrorWrapper(f ErrorWrapper) ContainerOption {
+	return func(cfg *containerConfig) {
+		cfg.errorWrapper = f
+	}
+}
+
+// WithMaxDepth returns a [ContainerOption] that sets the maximum depth of the dependency chain while building a service.
+//
+// If the chain is deeper, the build fails with [ErrMaxDepthExceeded].
+// It allows to detect a runaway recursion, that is not a cycle.
+// By default, the depth is unlimited.
+func WithMaxDepth(n int) ContainerOption {
+	return func(cfg *containerConfig) {
+		cfg.maxDepth = n
+	}
+}
+
+// WithoutDependencyTracking returns a [ContainerOption] that disables the tracking of the dependencies.
+//
+// It saves allocations while building the services.
+// [GetDependency], [Container.GetDependencyGraph] and [Container.Extract] return [ErrDependencyTrackingDisabled].
+// [GetDependents] doesn't return anything.
+// By default, the dependencies are tracked.
+func WithoutDependencyTracking() ContainerOption {
+	return func(cfg *containerConfig) {
+		cfg.dependencyTrackingDisabled = true
+	}
+}
+
+// BuildHook is called before the build of a service.
+//
+// The returned [context.Context] is given to the [Builder], so it is visible to the builds of the dependencies.
+// The returned function is called after the build, with the error.
+//
+// See [WithBuildHook].
+type BuildHook func(ctx context.Context, key Key) (context.Context, func(err error))
+
+// WithBuildHook returns a [ContainerOption] that sets the [BuildHook] of the [Container].
+//
+// It allows to integrate with tracing libraries.
+func WithBuildHook(h BuildHook) ContainerOption {
+	return func(cfg *containerConfig) {
+		cfg.buildHook = h
+	}
+}
+
+func (cfg *containerConfig) getDependencyType(key Key, typ reflect.Type) string {
+	if cfg.typeNamer != nil {
+		return cfg.typeNamer(typ)
+	}
+	return key.Type
+}
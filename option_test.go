@@ -0,0 +1,163 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithTypeNamer(t *testing.T) {
+	ctx := context.Background()
+	ctn := NewContainer(WithTypeNamer(func(typ reflect.Type) string {
+		return "custom_" + typ.Name()
+	}))
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[int](ctx, ctn, "")
+		return "", nil, nil
+	})
+	MustSetValue(ctn, "", 0)
+	dep, err := GetDependency[string](ctx, ctn, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, dep.Type, "custom_string")
+	assert.Equal(t, dep.Dependencies[0].Type, "custom_int")
+}
+
+type testWrappedError struct {
+	err error
+	key Key
+}
+
+func (err *testWrappedError) Error() string {
+	return "wrapped " + err.key.String() + ": " + err.err.Error()
+}
+
+func (err *testWrappedError) Unwrap() error {
+	return err.err
+}
+
+func TestWithErrorWrapper(t *testing.T) {
+	ctx := context.Background()
+	ctn := NewContainer(WithErrorWrapper(func(err error, key Key) error {
+		return &testWrappedError{
+			err: err,
+			key: key,
+		}
+	}))
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		_, err := Get[int](ctx, ctn, "")
+		return "", nil, err
+	})
+	_, err := Get[string](ctx, ctn, "")
+	assert.ErrorIs(t, err, ErrNotSet)
+	var wrappedErr *testWrappedError
+	assert.ErrorAs(t, err, &wrappedErr)
+	assert.Equal(t, wrappedErr.key, newKey[string](""))
+	assert.ErrorEqual(t, err, "wrapped string: wrapped int: not set")
+}
+
+func TestWithMaxDepth(t *testing.T) {
+	ctx := context.Background()
+	ctn := NewContainer(WithMaxDepth(3))
+	for i := range 5 {
+		MustSet(ctn, strconv.Itoa(i), func(ctx context.Context, ctn *Container) (int, Close, error) {
+			if i == 0 {
+				return 0, nil, nil
+			}
+			v, err := Get[int](ctx, ctn, strconv.Itoa(i-1))
+			return v + 1, nil, err
+		})
+	}
+	_, err := Get[int](ctx, ctn, "4")
+	assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+	assert.ErrorEqual(t, err, "service int(4): service int(3): service int(2): service int(1): max depth exceeded")
+	v, err := Get[int](ctx, ctn, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, v, 2)
+	v, err = Get[int](ctx, ctn, "4")
+	assert.NoError(t, err)
+	assert.Equal(t, v, 4)
+}
+
+type testBuildHookContextKey struct{}
+
+func TestWithBuildHook(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	ctn := NewContainer(WithBuildHook(func(ctx context.Context, key Key) (context.Context, func(err error)) {
+		parent, _ := ctx.Value(testBuildHookContextKey{}).(string)
+		calls = append(calls, "start "+key.Name+" parent="+parent)
+		ctx = context.WithValue(ctx, testBuildHookContextKey{}, key.Name)
+		return ctx, func(err error) {
+			calls = append(calls, fmt.Sprintf("end %s err=%v", key.Name, err))
+		}
+	}))
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "b")
+		return "", nil, nil
+	})
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		panic("test")
+	})
+	_, err := Get[string](ctx, ctn, "a")
+	assert.Error(t, err)
+	assert.DeepEqual(t, calls, []string{
+		"start a parent=",
+		"start b parent=a",
+		"end b err=panic: test",
+		"end a err=panic: service string(b): panic: test",
+	})
+}
+
+func TestWithoutDependencyTracking(t *testing.T) {
+	ctx := context.Background()
+	ctn := NewContainer(WithoutDependencyTracking())
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return MustGet[string](ctx, ctn, "b"), nil, nil
+	})
+	MustSetValue(ctn, "b", "b")
+	s := MustGet[string](ctx, ctn, "a")
+	assert.Equal(t, s, "b")
+	_, err := GetDependency[string](ctx, ctn, "a")
+	assert.ErrorIs(t, err, ErrDependencyTrackingDisabled)
+	_, err = ctn.GetDependencyGraph(ctx)
+	assert.ErrorIs(t, err, ErrDependencyTrackingDisabled)
+	keys, err := GetDependents[string](ctx, ctn, "b")
+	assert.NoError(t, err)
+	assert.SliceEmpty(t, keys)
+}
+
+func TestWithoutDependencyTrackingChild(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	child := NewContainer(WithoutDependencyTracking())
+	MustSetValue(child, "b", "b")
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return MustGet[string](ctx, child, "b"), nil, nil
+	})
+	dep := MustGetDependency[string](ctx, ctn, "a")
+	assert.SliceEmpty(t, dep.Dependencies)
+}
+
+func BenchmarkBuild(b *testing.B) {
+	benchmarkBuild(b, new(Container))
+}
+
+func BenchmarkBuildWithoutDependencyTracking(b *testing.B) {
+	benchmarkBuild(b, NewContainer(WithoutDependencyTracking()))
+}
+
+func benchmarkBuild(b *testing.B, ctn *Container) {
+	b.Helper()
+	ctx := context.Background()
+	MustSetFactory(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "test", nil, nil
+	})
+	b.ResetTimer()
+	for range b.N {
+		_, _ = Get[string](ctx, ctn, "")
+	}
+}
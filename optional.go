@@ -0,0 +1,23 @@
+package di
+
+// SetOptional calls [Set] with [WithOptional].
+func SetOptional[S any](ctn *Container, name string, b Builder[S], opts ...ServiceOption) error {
+	return Set(ctn, name, b, append([]ServiceOption{WithOptional()}, opts...)...)
+}
+
+// MustSetOptional calls [SetOptional] and panics if there is an error.
+func MustSetOptional[S any](ctn *Container, name string, b Builder[S], opts ...ServiceOption) {
+	MustSet(ctn, name, b, append([]ServiceOption{WithOptional()}, opts...)...)
+}
+
+// WithOptional returns a [ServiceOption] that makes a service optional.
+//
+// If the build of an optional service fails during [Container.Initialize] or [Container.InitializeParallel], the error is logged (see [WithLogger]) and ignored, so it doesn't fail the startup.
+// The services that depend on it still fail.
+//
+// A failed optional service remains not initialized: the next [Get] builds it again, and returns the error if it fails.
+func WithOptional() ServiceOption {
+	return func(cfg *serviceConfig) {
+		cfg.optional = true
+	}
+}
@@ -0,0 +1,69 @@
+package di
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestSetOptional(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name       string
+		initialize func(ctx context.Context, ctn *Container) error
+	}{
+		{
+			name: "Initialize",
+			initialize: func(ctx context.Context, ctn *Container) error {
+				return ctn.Initialize(ctx)
+			},
+		},
+		{
+			name: "InitializeParallel",
+			initialize: func(ctx context.Context, ctn *Container) error {
+				return ctn.InitializeParallel(ctx, 2)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			ctn := NewContainer(WithLogger(slog.New(slog.NewTextHandler(buf, nil))))
+			calls := 0
+			err := SetOptional(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+				calls++
+				return "", nil, errors.New("error")
+			})
+			assert.NoError(t, err)
+			MustSetValue(ctn, "b", "b")
+			err = tc.initialize(ctx, ctn)
+			assert.NoError(t, err)
+			assert.StringContains(t, buf.String(), `level=WARN msg="optional service build failed" key=string(a) error="service string(a): error"`)
+			assert.Equal(t, calls, 1)
+			_, err = Get[string](ctx, ctn, "a")
+			assert.ErrorEqual(t, err, "service string(a): error")
+			assert.Equal(t, calls, 2)
+			assert.Equal(t, MustGet[string](ctx, ctn, "b"), "b")
+		})
+	}
+}
+
+func TestMustSetOptionalDependent(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetOptional(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, errors.New("error")
+	})
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		_, err := Get[string](ctx, ctn, "a")
+		return "", nil, err
+	})
+	err := ctn.Initialize(ctx)
+	assert.ErrorEqual(t, err, "service string(b): service string(a): error")
+	assert.Panics(t, func() {
+		MustSetOptional(ctn, "a", newValueBuilder(""))
+	})
+}
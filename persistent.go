@@ -0,0 +1,24 @@
+package di
+
+// SetPersistent calls [Set] with [WithPersistent].
+func SetPersistent[S any](ctn *Container, name string, b Builder[S], opts ...ServiceOption) error {
+	return Set(ctn, name, b, append([]ServiceOption{WithPersistent()}, opts...)...)
+}
+
+// MustSetPersistent calls [SetPersistent] and panics if there is an error.
+func MustSetPersistent[S any](ctn *Container, name string, b Builder[S], opts ...ServiceOption) {
+	MustSet(ctn, name, b, append([]ServiceOption{WithPersistent()}, opts...)...)
+}
+
+// WithPersistent returns a [ServiceOption] that makes a service persistent.
+//
+// A normal service is closed by [Container.Close], and built again by the next [Get].
+// A persistent service lives as long as the [Container]: it is built once, and it is not closed by [Container.Close], [Container.CloseParallel], [Container.CloseWithTimeout] or [Container.CloseStream].
+//
+// It is only closed when it is explicitly removed or closed: by [Container.Reset], [Replace] or [CloseService], or when the [Container] is closed for good, by [Container.Shutdown] or [Container.Drain].
+// So [Container.AssertAllClosed] reports it until then.
+func WithPersistent() ServiceOption {
+	return func(cfg *serviceConfig) {
+		cfg.persistent = true
+	}
+}
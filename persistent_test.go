@@ -0,0 +1,52 @@
+package di
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestSetPersistent(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	builds, closes := 0, 0
+	err := SetPersistent(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		builds++
+		return "test", func(ctx context.Context) error {
+			closes++
+			return nil
+		}, nil
+	})
+	assert.NoError(t, err)
+	for range 3 {
+		s := MustGet[string](ctx, ctn, "")
+		assert.Equal(t, s, "test")
+		err = ctn.Close(ctx)
+		assert.NoError(t, err)
+	}
+	err = ctn.CloseParallel(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, builds, 1)
+	assert.Equal(t, closes, 0)
+	err = ctn.Reset(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closes, 1)
+}
+
+func TestMustSetPersistentCloseService(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	builds := 0
+	MustSetPersistent(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		builds++
+		return "test", nil, nil
+	})
+	MustGet[string](ctx, ctn, "")
+	MustCloseService[string](ctx, ctn, "")
+	MustGet[string](ctx, ctn, "")
+	assert.Equal(t, builds, 2)
+	assert.Panics(t, func() {
+		MustSetPersistent(ctn, "", newValueBuilder(""))
+	})
+}
@@ -3,6 +3,8 @@ package di
 import (
 	"context"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // SetProvider sets a [Provider] to a [Container].
@@ -15,33 +17,89 @@ func MustSetProvider[S any](ctn *Container, name string) {
 	MustSet(ctn, name, newProviderBuilder[S](name))
 }
 
+// SetProviderTTL sets a [Provider] with a [Provider.TTL] to a [Container].
+func SetProviderTTL[S any](ctn *Container, name string, ttl time.Duration) error {
+	return Set(ctn, name, newProviderTTLBuilder[S](name, ttl))
+}
+
+// MustSetProviderTTL calls [MustSet] for a [Provider] with a [Provider.TTL].
+func MustSetProviderTTL[S any](ctn *Container, name string, ttl time.Duration) {
+	MustSet(ctn, name, newProviderTTLBuilder[S](name, ttl))
+}
+
 func newProviderBuilder[S any](name string) Builder[*Provider[S]] {
+	return newProviderTTLBuilder[S](name, 0)
+}
+
+func newProviderTTLBuilder[S any](name string, ttl time.Duration) Builder[*Provider[S]] {
 	return func(ctx context.Context, ctn *Container) (*Provider[S], Close, error) {
 		p := newProvider[S](ctn, name)
+		p.TTL = ttl
 		return p, p.Close, nil
 	}
 }
 
-// GetProvider returns a [Provider] from a [Container].
-func GetProvider[S any](ctx context.Context, ctn *Container, name string) (*Provider[S], error) {
-	return Get[*Provider[S]](ctx, ctn, name)
+// GetProvider returns a [Provider] from a [Resolver].
+//
+// From a [ReadOnlyContainer], it returns a new [Provider] that resolves the service from the [ReadOnlyContainer], so the [Container] is not exposed.
+func GetProvider[S any](ctx context.Context, ctn Resolver, name string) (*Provider[S], error) {
+	p, err := Get[*Provider[S]](ctx, ctn, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.forResolver(ctn), nil
+}
+
+// MustGetProvider calls [GetProvider] and panics if there is an error.
+func MustGetProvider[S any](ctx context.Context, ctn Resolver, name string) *Provider[S] {
+	p, err := GetProvider[S](ctx, ctn, name)
+	if err != nil {
+		panic(err)
+	}
+	return p
 }
 
-// MustGetProvider calls [MustGet] for a [Provider].
-func MustGetProvider[S any](ctx context.Context, ctn *Container, name string) *Provider[S] {
-	return MustGet[*Provider[S]](ctx, ctn, name)
+// GetAllProviders returns all [Provider]s of a type from a [Resolver].
+//
+// The key of the map is the name of the [Provider], as in [GetAll].
+// Each [Provider] caches its service independently.
+// From a [ReadOnlyContainer], the [Provider]s are new, as in [GetProvider].
+func GetAllProviders[S any](ctx context.Context, ctn Resolver) (map[string]*Provider[S], error) {
+	ps, err := GetAll[*Provider[S]](ctx, ctn)
+	if err != nil {
+		return nil, err
+	}
+	for name, p := range ps {
+		ps[name] = p.forResolver(ctn)
+	}
+	return ps, nil
 }
 
 // Provider provides a service.
 //
 // It can be used to break circular dependencies.
 type Provider[S any] struct {
+	// Container is the [Container] that resolves the service.
+	// It is nil for a [Provider] returned by [GetProvider] from a [ReadOnlyContainer].
 	Container *Container
 	Name      string
+	// TTL is the duration after which the cached service expires, and is resolved again from the [Container].
+	// A new value is only returned if the [Container] has rebuilt the service (e.g. with [Container.Close] or [Replace]).
+	// If it is 0, the service never expires.
+	TTL time.Duration
 
-	mu          sync.Mutex
-	initialized bool
-	service     S
+	resolver Resolver // It is used instead of Container, if it is not nil.
+	muOnce   sync.Once
+	mu       chan struct{} // It is a lock that can be acquired with a [context.Context], initialized lazily so the zero value is usable.
+	value    atomic.Pointer[providerValue[S]]
+}
+
+// providerValue is the cached service of a [Provider].
+//
+// It is immutable, so it can be read without locking.
+type providerValue[S any] struct {
+	service   S
+	expiresAt time.Time
 }
 
 func newProvider[S any](ctn *Container, name string) *Provider[S] {
@@ -51,19 +109,66 @@ func newProvider[S any](ctn *Container, name string) *Provider[S] {
 	}
 }
 
+// forResolver returns a [Provider] that doesn't expose the [Container], if the [Resolver] is a [ReadOnlyContainer].
+func (p *Provider[S]) forResolver(ctn Resolver) *Provider[S] {
+	r, ok := ctn.(*ReadOnlyContainer)
+	if !ok {
+		return p
+	}
+	rp := newProvider[S](nil, p.Name)
+	rp.TTL = p.TTL
+	rp.resolver = r
+	return rp
+}
+
+func (p *Provider[S]) getResolver() Resolver {
+	if p.resolver != nil {
+		return p.resolver
+	}
+	return p.Container
+}
+
 // Get returns the service.
+//
+// Once the service is cached, it doesn't lock.
 func (p *Provider[S]) Get(ctx context.Context) (S, error) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	if p.initialized {
-		return p.service, nil
+	v, ok := p.getCached()
+	if ok {
+		return v.service, nil
 	}
-	s, err := Get[S](ctx, p.Container, p.Name)
+	return p.getSlow(ctx)
+}
+
+func (p *Provider[S]) getCached() (*providerValue[S], bool) {
+	v := p.value.Load()
+	if v == nil || (p.TTL > 0 && !time.Now().Before(v.expiresAt)) {
+		return nil, false
+	}
+	return v, true
+}
+
+func (p *Provider[S]) getSlow(ctx context.Context) (s S, err error) {
+	select {
+	case p.lockChan() <- struct{}{}:
+	case <-ctx.Done():
+		return s, ctx.Err() //nolint:wrapcheck // We don't need to wrap.
+	}
+	defer p.unlock()
+	v, ok := p.getCached()
+	if ok {
+		return v.service, nil
+	}
+	s, err = Get[S](setOptionalDependencyToContext(ctx), p.getResolver(), p.Name)
 	if err != nil {
 		return s, err
 	}
-	p.initialized = true
-	p.service = s
+	v = &providerValue[S]{
+		service: s,
+	}
+	if p.TTL > 0 {
+		v.expiresAt = time.Now().Add(p.TTL)
+	}
+	p.value.Store(v)
 	return s, nil
 }
 
@@ -76,18 +181,57 @@ func (p *Provider[S]) MustGet(ctx context.Context) S {
 	return s
 }
 
+// GetWithTimeout calls [Provider.Get] with a timeout.
+//
+// The timeout only applies if the service is not cached.
+// It includes the wait for another call that is resolving the service.
+// The build uses the canceled [context.Context], so the [Provider] is unlocked when it returns.
+// A [Builder] that ignores the [context.Context] can exceed the timeout.
+func (p *Provider[S]) GetWithTimeout(ctx context.Context, d time.Duration) (S, error) {
+	v, ok := p.getCached()
+	if ok {
+		return v.service, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	return p.getSlow(ctx)
+}
+
+// MustGetWithTimeout calls [Provider.GetWithTimeout] and panics if there is an error.
+func (p *Provider[S]) MustGetWithTimeout(ctx context.Context, d time.Duration) S {
+	s, err := p.GetWithTimeout(ctx, d)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
 // Close closes the [Provider].
 //
 // It doesn't close the service.
 //
 // The [Provider] can be used again after being closed.
 func (p *Provider[S]) Close(ctx context.Context) error {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	if p.initialized {
-		p.initialized = false
-		var zero S
-		p.service = zero
-	}
+	p.Refresh()
 	return nil
 }
+
+// Refresh discards the cached service, so the next [Provider.Get] resolves it again from the [Container].
+//
+// It doesn't close the service.
+func (p *Provider[S]) Refresh() {
+	p.lockChan() <- struct{}{}
+	defer p.unlock()
+	p.value.Store(nil)
+}
+
+func (p *Provider[S]) lockChan() chan struct{} {
+	p.muOnce.Do(func() {
+		p.mu = make(chan struct{}, 1)
+	})
+	return p.mu
+}
+
+func (p *Provider[S]) unlock() {
+	<-p.mu
+}
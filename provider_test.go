@@ -2,8 +2,10 @@ package di
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/pierrre/assert"
 )
@@ -44,6 +46,34 @@ func TestProvider(t *testing.T) {
 	}
 }
 
+func TestProviderReadOnly(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "", "test")
+	MustSetProvider[string](ctn, "")
+	p := MustGetProvider[string](ctx, ctn.ReadOnly(), "")
+	assert.Zero(t, p.Container)
+	assert.Equal(t, p.MustGet(ctx), "test")
+	ps, err := GetAllProviders[string](ctx, ctn.ReadOnly())
+	assert.NoError(t, err)
+	for _, p := range ps {
+		assert.Zero(t, p.Container)
+		assert.Equal(t, p.MustGet(ctx), "test")
+	}
+}
+
+func TestProviderZeroValue(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "", "test")
+	p := &Provider[string]{Container: ctn}
+	p.Refresh()
+	assert.Equal(t, p.MustGetWithTimeout(ctx, time.Second), "test")
+	err := p.Close(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, p.MustGet(ctx), "test")
+}
+
 func TestMustSetProviderPanic(t *testing.T) {
 	ctn := new(Container)
 	MustSetProvider[string](ctn, "")
@@ -72,6 +102,87 @@ func TestProviderGetAllocs(t *testing.T) {
 	}, 0)
 }
 
+func TestGetAllProviders(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	for _, name := range []string{"a", "b"} {
+		MustSetValue(ctn, name, name)
+		MustSetProvider[string](ctn, name)
+	}
+	MustSetValue(ctn, "c", "c")
+	ps, err := GetAllProviders[string](ctx, ctn)
+	assert.NoError(t, err)
+	assert.MapLen(t, ps, 2)
+	for name, p := range ps {
+		assert.Equal(t, p.MustGet(ctx), name)
+	}
+}
+
+func TestGetAllProvidersError(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (*Provider[string], Close, error) {
+		return nil, nil, errors.New("error")
+	})
+	_, err := GetAllProviders[string](ctx, ctn)
+	assert.Error(t, err)
+}
+
+func TestProviderRefresh(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "", 1)
+	p := newProvider[int](ctn, "")
+	assert.Equal(t, p.MustGet(ctx), 1)
+	MustReplace(ctx, ctn, "", newValueBuilder(2))
+	assert.Equal(t, p.MustGet(ctx), 1)
+	p.Refresh()
+	assert.Equal(t, p.MustGet(ctx), 2)
+}
+
+func TestProviderTTL(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	i := 0
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (int, Close, error) {
+		i++
+		return i, nil, nil
+	})
+	err := SetProviderTTL[int](ctn, "", 1*time.Hour)
+	assert.NoError(t, err)
+	p := MustGetProvider[int](ctx, ctn, "")
+	assert.Equal(t, p.MustGet(ctx), 1)
+	err = ctn.Close(ctx)
+	assert.NoError(t, err)
+	p = MustGetProvider[int](ctx, ctn, "")
+	assert.Equal(t, p.MustGet(ctx), 2)
+	MustReplace(ctx, ctn, "", func(ctx context.Context, ctn *Container) (int, Close, error) {
+		return 10, nil, nil
+	})
+	assert.Equal(t, p.MustGet(ctx), 2)
+	p.value.Load().expiresAt = time.Now().Add(-1 * time.Second) // Simulate expiration.
+	assert.Equal(t, p.MustGet(ctx), 10)
+}
+
+func TestMustSetProviderTTLPanic(t *testing.T) {
+	ctn := new(Container)
+	MustSetProviderTTL[string](ctn, "", 1*time.Second)
+	assert.Panics(t, func() {
+		MustSetProviderTTL[string](ctn, "", 1*time.Second)
+	})
+}
+
+func TestProviderTTLGetAllocs(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "", "test")
+	p := newProvider[string](ctn, "")
+	p.TTL = 1 * time.Hour
+	assert.AllocsPerRun(t, 100, func() {
+		p.MustGet(ctx)
+	}, 0)
+}
+
 func TestProviderGetError(t *testing.T) {
 	ctx := context.Background()
 	ctn := new(Container)
@@ -93,6 +204,63 @@ func TestProviderMustGetPanic(t *testing.T) {
 	})
 }
 
+func TestProviderGetWithTimeout(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	calls := 0
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		calls++
+		if calls == 1 {
+			<-ctx.Done()
+			return "", nil, ctx.Err()
+		}
+		return "test", nil, nil
+	})
+	p := newProvider[string](ctn, "")
+	_, err := p.GetWithTimeout(ctx, 10*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	s := p.MustGetWithTimeout(ctx, 1*time.Second)
+	assert.Equal(t, s, "test")
+	s = p.MustGetWithTimeout(ctx, 0)
+	assert.Equal(t, s, "test")
+}
+
+func TestProviderGetWithTimeoutLocked(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		close(started)
+		<-release
+		return "test", nil, nil
+	})
+	p := newProvider[string](ctn, "")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.MustGet(ctx)
+	}()
+	<-started
+	start := time.Now()
+	_, err := p.GetWithTimeout(ctx, 10*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 1*time.Second)
+	close(release)
+	<-done
+	s := p.MustGetWithTimeout(ctx, 1*time.Second)
+	assert.Equal(t, s, "test")
+}
+
+func TestProviderMustGetWithTimeoutPanic(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	p := newProvider[string](ctn, "")
+	assert.Panics(t, func() {
+		p.MustGetWithTimeout(ctx, 1*time.Second)
+	})
+}
+
 func BenchmarkProviderGet(b *testing.B) {
 	ctx := context.Background()
 	ctn := new(Container)
@@ -105,3 +273,16 @@ func BenchmarkProviderGet(b *testing.B) {
 		_, _ = p.Get(ctx)
 	}
 }
+
+func BenchmarkProviderGetParallel(b *testing.B) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "", "test")
+	p := newProvider[string](ctn, "")
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = p.Get(ctx)
+		}
+	})
+}
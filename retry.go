@@ -0,0 +1,109 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// SetWithRetry calls [Set] with a [Builder] that is retried according to the [RetryPolicy].
+//
+// Only the errors are retried, not the panics.
+// The errors that can't be fixed by a retry ([ErrCycle], [ErrNotSet]) are not retried.
+// Once the service is built, it is cached as usual.
+// The [Close] functions returned by the failed attempts are kept, and called with the [Close] function of the service.
+func SetWithRetry[S any](ctn *Container, name string, policy RetryPolicy, b Builder[S], opts ...ServiceOption) error {
+	return Set(ctn, name, newRetryBuilder(policy, b), opts...)
+}
+
+// MustSetWithRetry calls [SetWithRetry] and panics if there is an error.
+func MustSetWithRetry[S any](ctn *Container, name string, policy RetryPolicy, b Builder[S], opts ...ServiceOption) {
+	MustSet(ctn, name, newRetryBuilder(policy, b), opts...)
+}
+
+// RetryPolicy configures the retries of [SetWithRetry].
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of calls to the [Builder].
+	// A value lower than 1 means 1.
+	MaxAttempts int
+	// Backoff is the delay before the first retry.
+	// It is doubled after each retry.
+	Backoff time.Duration
+	// MaxBackoff is the maximum delay between retries.
+	// A value of 0 means no limit.
+	MaxBackoff time.Duration
+}
+
+func (p RetryPolicy) nextBackoff(d time.Duration) time.Duration {
+	d *= 2
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+func newRetryBuilder[S any](policy RetryPolicy, b Builder[S]) Builder[S] {
+	return func(ctx context.Context, ctn *Container) (S, Close, error) {
+		backoff := policy.Backoff
+		var failedCls []Close
+		for attempt := 1; ; attempt++ {
+			s, cl, err := callRetryAttempt(ctx, ctn, b)
+			if err == nil {
+				return s, chainRetryCloses(cl, failedCls), nil
+			}
+			failedCls = append(failedCls, cl)
+			if !isRetryableError(err) {
+				return s, chainRetryCloses(nil, failedCls), err
+			}
+			if attempt >= policy.MaxAttempts {
+				return s, chainRetryCloses(nil, failedCls), fmt.Errorf("after %d attempts: %w", attempt, err)
+			}
+			sleepErr := sleepContext(ctx, backoff)
+			if sleepErr != nil {
+				return s, chainRetryCloses(nil, failedCls), errors.Join(fmt.Errorf("after %d attempts: %w", attempt, err), sleepErr)
+			}
+			backoff = policy.nextBackoff(backoff)
+		}
+	}
+}
+
+// callRetryAttempt calls the [Builder] with its own dependency collector, so the dependencies of the failed attempts are not recorded.
+func callRetryAttempt[S any](ctx context.Context, ctn *Container, b Builder[S]) (S, Close, error) {
+	actx, dc := addDependencyCollectorToContext(ctx)
+	s, cl, err := b(actx, ctn)
+	if err != nil {
+		return s, cl, err
+	}
+	for _, d := range dc.dependencies {
+		addDependencyToCollectorFromContext(ctx, d)
+	}
+	return s, cl, nil
+}
+
+// isRetryableError returns false for the errors that are returned again by the next attempts.
+func isRetryableError(err error) bool {
+	return !errors.Is(err, ErrCycle) && !errors.Is(err, ErrNotSet)
+}
+
+// chainRetryCloses returns a [Close] function that calls the [Close] function of the service, then the [Close] functions of the failed attempts in the reverse order.
+func chainRetryCloses(cl Close, failedCls []Close) Close {
+	cls := make([]Close, 0, len(failedCls)+1)
+	cls = append(cls, cl)
+	for _, fcl := range slices.Backward(failedCls) {
+		cls = append(cls, fcl)
+	}
+	return chainCloses(cls...)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // We don't need to wrap.
+	}
+}
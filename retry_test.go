@@ -0,0 +1,143 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func TestSetWithRetry(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "dep", "dep")
+	calls := 0
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     1 * time.Millisecond,
+	}
+	err := SetWithRetry(ctn, "", policy, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		calls++
+		MustGet[string](ctx, ctn, "dep")
+		if calls < 3 {
+			return "", nil, errors.New("error")
+		}
+		return "test", nil, nil
+	})
+	assert.NoError(t, err)
+	for range 2 {
+		s, err := Get[string](ctx, ctn, "")
+		assert.NoError(t, err)
+		assert.Equal(t, s, "test")
+	}
+	assert.Equal(t, calls, 3)
+	dep, err := GetDependency[string](ctx, ctn, "")
+	assert.NoError(t, err)
+	assert.SliceLen(t, dep.Dependencies, 1)
+}
+
+func TestSetWithRetryError(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	calls := 0
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     1 * time.Millisecond,
+		MaxBackoff:  1 * time.Millisecond,
+	}
+	MustSetWithRetry(ctn, "", policy, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		calls++
+		return "", nil, errors.New("error")
+	})
+	_, err := Get[string](ctx, ctn, "")
+	var serviceErr *ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+	assert.ErrorEqual(t, err, "service string: after 3 attempts: error")
+	assert.Equal(t, calls, 3)
+}
+
+func TestSetWithRetryCloseFailedAttempts(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var closes []int
+	calls := 0
+	MustSetWithRetry(ctn, "", RetryPolicy{MaxAttempts: 3}, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		calls++
+		call := calls
+		cl := func(ctx context.Context) error {
+			closes = append(closes, call)
+			return nil
+		}
+		if call < 3 {
+			return "", cl, errors.New("error")
+		}
+		return "test", cl, nil
+	})
+	MustGet[string](ctx, ctn, "")
+	err := ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, closes, []int{3, 2, 1})
+}
+
+func TestSetWithRetryErrorCloseFailedAttempts(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	closeCount := 0
+	MustSetWithRetry(ctn, "", RetryPolicy{MaxAttempts: 2}, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", func(ctx context.Context) error {
+			closeCount++
+			return nil
+		}, errors.New("error")
+	})
+	_, err := Get[string](ctx, ctn, "")
+	assert.Error(t, err)
+	err = ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closeCount, 2)
+}
+
+func TestSetWithRetryErrorNotRetryable(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	calls := 0
+	MustSetWithRetry(ctn, "", RetryPolicy{MaxAttempts: 3}, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		calls++
+		_, err := Get[int](ctx, ctn, "")
+		return "", nil, err
+	})
+	_, err := Get[string](ctx, ctn, "")
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.ErrorEqual(t, err, "service string: service int: not set")
+	assert.Equal(t, calls, 1)
+}
+
+func TestSetWithRetryErrorContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctn := new(Container)
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     1 * time.Hour,
+	}
+	MustSetWithRetry(ctn, "", policy, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		cancel()
+		return "", nil, errors.New("error")
+	})
+	_, err := Get[string](ctx, ctn, "")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSetWithRetryPanic(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	calls := 0
+	MustSetWithRetry(ctn, "", RetryPolicy{MaxAttempts: 3}, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		calls++
+		panic("test")
+	})
+	_, err := Get[string](ctx, ctn, "")
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, calls, 1)
+}
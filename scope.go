@@ -0,0 +1,108 @@
+package di
+
+import (
+	"context"
+	"reflect"
+)
+
+// SetScoped sets a scoped service to a [Container].
+//
+// The service has an instance per scope, that is built by the first [Get] in this scope, and cached.
+// The scope is identified by the function, from the [context.Context] given to [Get] (e.g. a request ID).
+// All the instances are closed by [Container.Close].
+//
+// The instances are kept until [Container.Close] is called.
+// So the memory grows with the number of scopes, and it should not be used with unbounded scopes in a long-lived [Container].
+//
+// It is implemented as a factory service, see [SetFactory].
+func SetScoped[S any](ctn *Container, name string, scopeKey func(ctx context.Context) string, b Builder[S], opts ...ServiceOption) error {
+	key := newKey[S](name)
+	typ := reflect.TypeFor[S]()
+	sw := newServiceWrapper(key, typ, newBuilder(b), opts)
+	sw.factory = true
+	sw.scopeKey = scopeKey
+	return ctn.setServiceWrapper(sw)
+}
+
+// MustSetScoped calls [SetScoped] and panics if there is an error.
+func MustSetScoped[S any](ctn *Container, name string, scopeKey func(ctx context.Context) string, b Builder[S], opts ...ServiceOption) {
+	err := SetScoped(ctn, name, scopeKey, b, opts...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GetScoped calls [Get] with a scoped value, that can be retrieved by the [Builder] with [ScopedValue].
+//
+// The scoped value is visible to the builders of the dependencies.
+// Only the services that are built by this call see it: the services that are already initialized are not built again.
+// So it is mostly useful with factory services (see [SetFactory]).
+func GetScoped[V, S any](ctx context.Context, ctn Resolver, name string, scopeValue V) (S, error) {
+	ctx = WithBuildValue(ctx, scopedValueContextKey[V]{}, scopeValue)
+	return Get[S](ctx, ctn, name)
+}
+
+// MustGetScoped calls [GetScoped] and panics if there is an error.
+func MustGetScoped[V, S any](ctx context.Context, ctn Resolver, name string, scopeValue V) S {
+	s, err := GetScoped[V, S](ctx, ctn, name, scopeValue)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// ScopedValue returns the scoped value of type V provided to [GetScoped].
+//
+// It returns false if there is no scoped value of this type.
+func ScopedValue[V any](ctx context.Context) (V, bool) {
+	return BuildValue[V](ctx, scopedValueContextKey[V]{})
+}
+
+type scopedValueContextKey[V any] struct{}
+
+// WithBuildValue returns a [context.Context] that contains a value, that can be retrieved by the [Builder] with [BuildValue].
+//
+// The values of the [context.Context] given to [Get] are visible to the builders of the service and its dependencies.
+// The key is wrapped in a private type, so it doesn't collide with other context keys.
+// As with [context.WithValue], the key must be comparable, otherwise it panics.
+func WithBuildValue(ctx context.Context, key, val any) context.Context {
+	if key == nil {
+		panic("nil key")
+	}
+	if !reflect.TypeOf(key).Comparable() {
+		panic("key is not comparable")
+	}
+	return context.WithValue(ctx, buildValueContextKey{key: key}, val)
+}
+
+// BuildValue returns the value of type T for the key provided to [WithBuildValue].
+//
+// It returns false if there is no value for the key, or if it doesn't have the type T.
+// A key that is not comparable never has a value.
+func BuildValue[T any](ctx context.Context, key any) (T, bool) {
+	if key == nil || !reflect.TypeOf(key).Comparable() {
+		var zero T
+		return zero, false
+	}
+	v, ok := ctx.Value(buildValueContextKey{key: key}).(T)
+	return v, ok
+}
+
+type buildValueContextKey struct {
+	key any
+}
+
+// BuildChain returns the keys of the services that are being built, from the current service to the root service.
+//
+// In a [Builder], the first [Key] is the service being built, and the second [Key] is the service that depends on it.
+// It is only populated during a build initiated by [Get] (or a function that calls it).
+// Otherwise, it returns nil.
+func BuildChain(ctx context.Context) []Key {
+	var keys []Key
+	for st := getBuildState(ctx); st != nil; st = st.previous {
+		if st.mu != nil {
+			keys = append(keys, st.mu.key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,193 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+type testTenantID string
+
+type testScopeContextKey struct{}
+
+func getTestScope(ctx context.Context) string {
+	s, _ := ctx.Value(testScopeContextKey{}).(string)
+	return s
+}
+
+func TestSetScoped(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	builderCalled := 0
+	closeCalled := 0
+	MustSetScoped(ctn, "", getTestScope, func(ctx context.Context, ctn *Container) (*int, Close, error) {
+		builderCalled++
+		i := builderCalled
+		return &i, func(ctx context.Context) error {
+			closeCalled++
+			return nil
+		}, nil
+	})
+	ctxA := context.WithValue(ctx, testScopeContextKey{}, "a")
+	ctxB := context.WithValue(ctx, testScopeContextKey{}, "b")
+	a1 := MustGet[*int](ctxA, ctn, "")
+	a2 := MustGet[*int](ctxA, ctn, "")
+	b1 := MustGet[*int](ctxB, ctn, "")
+	assert.Equal(t, a1, a2)
+	assert.NotEqual(t, a1, b1)
+	assert.Equal(t, builderCalled, 2)
+	err := ctn.Close(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closeCalled, 2)
+	a3 := MustGet[*int](ctxA, ctn, "")
+	assert.NotEqual(t, a1, a3)
+	assert.Equal(t, builderCalled, 3)
+}
+
+func TestSetScopedDependency(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetScoped(ctn, "a", getTestScope, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return MustGet[string](ctx, ctn, "b"), nil, nil
+	})
+	MustSetValue(ctn, "b", "b")
+	MustSet(ctn, "c", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[string](ctx, ctn, "a")
+		return "", nil, nil
+	})
+	MustGet[string](ctx, ctn, "a")
+	dep := MustGetDependency[string](ctx, ctn, "c")
+	assert.SliceLen(t, dep.Dependencies, 1)
+	assert.SliceLen(t, dep.Dependencies[0].Dependencies, 1)
+}
+
+func TestSetScopedErrorBuilder(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	builderCalled := 0
+	MustSetScoped(ctn, "", getTestScope, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		builderCalled++
+		return "", nil, errors.New("error")
+	})
+	for range 2 {
+		_, err := Get[string](ctx, ctn, "")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, builderCalled, 2)
+}
+
+func TestMustSetScopedPanic(t *testing.T) {
+	ctn := new(Container)
+	MustSetValue(ctn, "", "")
+	assert.Panics(t, func() {
+		MustSetScoped(ctn, "", getTestScope, func(ctx context.Context, ctn *Container) (string, Close, error) {
+			return "", nil, nil
+		})
+	})
+}
+
+func TestGetScoped(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetFactory(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		tenantID, ok := ScopedValue[testTenantID](ctx)
+		if !ok {
+			return "", nil, nil
+		}
+		i := MustGet[int](ctx, ctn, "")
+		return string(tenantID) + "-" + strconv.Itoa(i), nil, nil
+	})
+	MustSetFactory(ctn, "", func(ctx context.Context, ctn *Container) (int, Close, error) {
+		_, ok := ScopedValue[testTenantID](ctx)
+		assert.True(t, ok)
+		_, ok = ScopedValue[string](ctx)
+		assert.False(t, ok)
+		return 1, nil, nil
+	})
+	s, err := GetScoped[testTenantID, string](ctx, ctn, "", "a")
+	assert.NoError(t, err)
+	assert.Equal(t, s, "a-1")
+	s = MustGetScoped[testTenantID, string](ctx, ctn, "", "b")
+	assert.Equal(t, s, "b-1")
+	s = MustGet[string](ctx, ctn, "")
+	assert.Equal(t, s, "")
+}
+
+func TestGetScopedErrorCycle(t *testing.T) {
+	ctx := context.Background()
+	ctn := newTestContainerCycle()
+	_, err := GetScoped[testTenantID, string](ctx, ctn, "a", "a")
+	assert.ErrorIs(t, err, ErrCycle)
+}
+
+func TestMustGetScopedPanic(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	assert.Panics(t, func() {
+		MustGetScoped[testTenantID, string](ctx, ctn, "", "a")
+	})
+}
+
+type testContextKey struct{}
+
+func TestBuildValue(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return MustGet[string](ctx, ctn, "b"), nil, nil
+	})
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		raw, _ := ctx.Value(testContextKey{}).(string)
+		v, ok := BuildValue[string](ctx, "tenant")
+		assert.True(t, ok)
+		_, ok = BuildValue[int](ctx, "tenant")
+		assert.False(t, ok)
+		_, ok = BuildValue[string](ctx, "other")
+		assert.False(t, ok)
+		return raw + "-" + v, nil, nil
+	})
+	ctx = context.WithValue(ctx, testContextKey{}, "raw")
+	ctx = WithBuildValue(ctx, "tenant", "a")
+	s := MustGet[string](ctx, ctn, "a")
+	assert.Equal(t, s, "raw-a")
+}
+
+func TestBuildValueNotComparable(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBuildValue(ctx, "a", "a")
+	_, ok := BuildValue[string](ctx, []string{})
+	assert.False(t, ok)
+	assert.Panics(t, func() {
+		WithBuildValue(ctx, []string{}, "a")
+	})
+	assert.Panics(t, func() {
+		WithBuildValue(ctx, nil, "a")
+	})
+}
+
+func TestBuildChain(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var chain []Key
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return MustGet[string](ctx, ctn, "b"), nil, nil
+	})
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		chain = BuildChain(ctx)
+		return "", nil, nil
+	})
+	MustGet[string](ctx, ctn, "a")
+	assert.DeepEqual(t, chain, []Key{
+		newKey[string]("b"),
+		newKey[string]("a"),
+	})
+}
+
+func TestBuildChainEmpty(t *testing.T) {
+	ctx := context.Background()
+	chain := BuildChain(ctx)
+	assert.SliceEmpty(t, chain)
+}
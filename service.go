@@ -2,52 +2,127 @@ package di
 
 import (
 	"context"
+	"errors"
 	"reflect"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type builder func(ctx context.Context, ctn *Container) (any, Close, error)
 
 type serviceWrapper struct {
-	mu          *mutex
-	key         Key
-	typ         reflect.Type
-	builder     builder
-	initialized bool
-	service     any
-	cl          Close
-	dependency  *Dependency
+	serviceConfig
+	mu            *mutex
+	replaced      atomic.Bool // Set when the [serviceWrapper] is replaced, so it is not built again.
+	sequence      atomic.Int64
+	buildDuration atomic.Int64
+	initialized   bool
+	service       any
+	cl            Close
+	closers       []Close
+	dependency    *Dependency
+	scopes        map[string]any
 }
 
-func newServiceWrapper(key Key, typ reflect.Type, b builder) *serviceWrapper {
-	return &serviceWrapper{
-		mu:      newMutex(),
+// serviceConfig is the registration of a service.
+// It doesn't contain any state, so it can be copied to another [serviceWrapper].
+type serviceConfig struct {
+	key          Key
+	typ          reflect.Type
+	builder      builder
+	factory      bool
+	buildSLA     time.Duration
+	buildTimeout time.Duration
+	persistent   bool
+	optional     bool
+	scopeKey     func(ctx context.Context) string
+	deps         []Key
+	depsDeclared bool
+}
+
+func newServiceWrapper(key Key, typ reflect.Type, b builder, opts []ServiceOption) *serviceWrapper {
+	cfg := serviceConfig{
 		key:     key,
 		typ:     typ,
 		builder: b,
 	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return newServiceWrapperFromConfig(cfg)
+}
+
+func newServiceWrapperFromConfig(cfg serviceConfig) *serviceWrapper {
+	return &serviceWrapper{
+		serviceConfig: cfg,
+		mu:            newMutex(cfg.key),
+	}
 }
 
 func (sw *serviceWrapper) get(ctx context.Context, ctn *Container) (any, error) {
-	ctx, err := sw.mu.lock(ctx)
+	s, _, err := sw.getService(ctx, ctn, false)
+	return s, err
+}
+
+// getWithClose returns the service and its [Close], that is detached from the [serviceWrapper].
+func (sw *serviceWrapper) getWithClose(ctx context.Context, ctn *Container) (any, Close, error) {
+	return sw.getService(ctx, ctn, true)
+}
+
+func (sw *serviceWrapper) getService(ctx context.Context, ctn *Container, detachClose bool) (any, Close, error) {
+	ctx, err := sw.mu.lock(ctx, ctn.config.maxDepth)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer sw.mu.unlock()
+	if sw.replaced.Load() {
+		return nil, nil, errReplaced
+	}
+	if sw.factory {
+		s, cl, err := sw.getFactory(ctx, ctn)
+		if err != nil {
+			return nil, nil, err
+		}
+		if detachClose {
+			return s, cl, nil
+		}
+		sw.addCloser(cl)
+		return s, nil, nil
+	}
 	err = sw.ensureInitialized(ctx, ctn)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	addDependencyToCollectorFromContext(ctx, sw.dependency)
-	return sw.service, nil
+	if detachClose {
+		cl := sw.cl
+		sw.cl = nil
+		return sw.service, cl, nil
+	}
+	return sw.service, nil, nil
 }
 
 func (sw *serviceWrapper) getDependency(ctx context.Context, ctn *Container) (*Dependency, error) {
-	ctx, err := sw.mu.lock(ctx)
+	ctx, err := sw.mu.lock(ctx, ctn.config.maxDepth)
 	if err != nil {
 		return nil, err
 	}
 	defer sw.mu.unlock()
+	if sw.replaced.Load() {
+		return nil, errReplaced
+	}
+	if sw.factory {
+		if sw.dependency == nil {
+			_, cl, err := sw.getFactory(ctx, ctn)
+			if err != nil {
+				return nil, err
+			}
+			sw.addCloser(cl)
+		}
+		return sw.dependency, nil
+	}
 	err = sw.ensureInitialized(ctx, ctn)
 	if err != nil {
 		return nil, err
@@ -55,50 +130,272 @@ func (sw *serviceWrapper) getDependency(ctx context.Context, ctn *Container) (*D
 	return sw.dependency, nil
 }
 
+// isInitialized returns true if the service is initialized.
+// A factory service is never initialized.
+func (sw *serviceWrapper) isInitialized() bool {
+	return !sw.factory && sw.sequence.Load() != 0
+}
+
+// getIfInitialized returns the service if it is initialized, without building it.
+func (sw *serviceWrapper) getIfInitialized(ctx context.Context) (any, bool, error) {
+	_, err := sw.mu.lock(ctx, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	defer sw.mu.unlock()
+	return sw.service, sw.initialized, nil
+}
+
+// hasClose returns true if the service has a [Close] function to call.
+func (sw *serviceWrapper) hasClose(ctx context.Context) (bool, error) {
+	_, err := sw.mu.lock(ctx, 0)
+	if err != nil {
+		return false, err
+	}
+	defer sw.mu.unlock()
+	return sw.cl != nil || len(sw.closers) > 0, nil
+}
+
+func (sw *serviceWrapper) getDependencyKeys(ctx context.Context) ([]Key, error) {
+	_, err := sw.mu.lock(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer sw.mu.unlock()
+	if sw.dependency == nil {
+		return nil, nil
+	}
+	keys := make([]Key, len(sw.dependency.Dependencies))
+	for i, d := range sw.dependency.Dependencies {
+		keys[i] = d.key
+	}
+	return keys, nil
+}
+
 func (sw *serviceWrapper) ensureInitialized(ctx context.Context, ctn *Container) (err error) {
-	defer recoverPanicToError(&err)
+	defer recoverPanicToError(ctx, &err)
 	if sw.initialized {
 		return nil
 	}
-	ctx, dc := addDependencyCollectorToContext(ctx)
-	s, cl, err := sw.builder(ctx, ctn)
+	s, cl, dep, err := sw.build(ctx, ctn)
 	if err != nil {
+		sw.addCloser(cl)
 		return err
 	}
 	sw.initialized = true
+	sw.sequence.Store(ctn.sequence.Add(1))
+	ctn.trackInit(sw.key)
 	sw.service = s
 	sw.cl = cl
-	sw.dependency = &Dependency{
-		Type:         sw.key.Type,
+	sw.dependency = dep
+	return nil
+}
+
+// getFactory builds a new instance of a factory service.
+//
+// The returned [Close] is not kept by the [serviceWrapper], see [serviceWrapper.addCloser].
+// For a scoped service, it is nil if the instance of the scope is already built.
+func (sw *serviceWrapper) getFactory(ctx context.Context, ctn *Container) (s any, cl Close, err error) {
+	defer recoverPanicToError(ctx, &err)
+	var scope string
+	if sw.scopeKey != nil {
+		scope = sw.scopeKey(ctx)
+		v, ok := sw.scopes[scope]
+		if ok {
+			addDependencyToCollectorFromContext(ctx, sw.dependency)
+			return v, nil, nil
+		}
+	}
+	s, cl, dep, err := sw.build(ctx, ctn)
+	if err != nil {
+		sw.addCloser(cl)
+		return nil, nil, err
+	}
+	if sw.scopeKey != nil {
+		if sw.scopes == nil {
+			sw.scopes = make(map[string]any)
+		}
+		sw.scopes[scope] = s
+	}
+	sw.sequence.Store(ctn.sequence.Add(1))
+	ctn.trackInit(sw.key)
+	if sw.dependency == nil {
+		sw.dependency = dep
+	}
+	addDependencyToCollectorFromContext(ctx, sw.dependency)
+	return s, cl, nil
+}
+
+// build builds the service.
+//
+// If it fails, the [Close] returned by the [Builder] (if any) is returned with the error, so it is not leaked.
+//
+// If the dependency tracking is disabled, the returned [Dependency] is nil.
+func (sw *serviceWrapper) build(ctx context.Context, ctn *Container) (any, Close, *Dependency, error) {
+	err := ctn.drain.enterBuild(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer ctn.drain.exit()
+	var dc *dependencyCollector
+	if !ctn.config.dependencyTrackingDisabled {
+		ctx, dc = addDependencyCollectorToContext(ctx)
+	}
+	ctn.emitEvent(Event{
+		Key:  sw.key,
+		Type: EventBuildStart,
+	})
+	start := time.Now()
+	s, cl, err := sw.callBuilder(ctx, ctn)
+	duration := time.Since(start)
+	ctn.emitEvent(Event{
+		Key:      sw.key,
+		Type:     EventBuildEnd,
+		Duration: duration,
+		Err:      err,
+	})
+	if err != nil {
+		return nil, cl, nil, err
+	}
+	sw.buildDuration.Store(int64(duration))
+	ctn.checkBuildSLA(sw.key, duration, sw.buildSLA)
+	ctn.logBuilt(ctx, sw.key, duration)
+	if dc == nil {
+		return s, cl, nil, nil
+	}
+	if ctn.config.strictDependencies && sw.depsDeclared {
+		err = sw.checkUndeclaredDependencies(dc.dependencies)
+		if err != nil {
+			return nil, cl, nil, err
+		}
+	}
+	dep := &Dependency{
+		key:          sw.key,
+		Type:         ctn.config.getDependencyType(sw.key, sw.typ),
 		reflectType:  sw.typ,
 		Name:         sw.key.Name,
+		Version:      sw.key.Version,
 		Dependencies: dc.dependencies,
 	}
-	return nil
+	return s, cl, dep, nil
 }
 
-func (sw *serviceWrapper) close(ctx context.Context) error {
-	ctx, err := sw.mu.lock(ctx)
+// addCloser keeps the [Close] returned by a factory build or a failed build, so it is called by [Container.Close].
+func (sw *serviceWrapper) addCloser(cl Close) {
+	if cl != nil {
+		sw.closers = append(sw.closers, cl)
+	}
+}
+
+func (sw *serviceWrapper) callBuilder(ctx context.Context, ctn *Container) (s any, cl Close, err error) {
+	if ctn.config.buildHook != nil {
+		var end func(err error)
+		ctx, end = ctn.config.buildHook(ctx, sw.key)
+		defer func() {
+			end(err)
+		}()
+	}
+	defer recoverPanicToError(ctx, &err)
+	if sw.buildTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sw.buildTimeout)
+		defer cancel()
+	}
+	return ctn.applyMiddlewares(sw.key, BuildFunc(sw.builder))(ctx, ctn)
+}
+
+func (sw *serviceWrapper) close(ctx context.Context, ctn *Container) error {
+	if isClosingContext(ctx) {
+		return ErrCloseReentrant
+	}
+	ctx, err := sw.mu.lock(ctx, 0)
 	if err != nil {
 		return err
 	}
 	defer sw.mu.unlock()
-	if !sw.initialized {
+	ctx = setClosingToContext(ctx)
+	ctn.trackClose(sw.key)
+	if sw.sequence.Load() == 0 && len(sw.closers) == 0 {
+		// Not initialized, or a factory that was not built, and no orphan closer.
 		return nil
 	}
+	ctn.emitEvent(Event{
+		Key:  sw.key,
+		Type: EventCloseStart,
+	})
+	start := time.Now()
+	if sw.factory {
+		err = sw.closeFactory(ctx)
+	} else {
+		err = sw.closeService(ctx)
+	}
+	ctn.emitEvent(Event{
+		Key:      sw.key,
+		Type:     EventCloseEnd,
+		Duration: time.Since(start),
+		Err:      err,
+	})
+	ctn.logClosed(ctx, sw.key, err)
+	return err
+}
+
+func (sw *serviceWrapper) closeService(ctx context.Context) error {
+	var err error
 	if sw.cl != nil {
 		err = sw.cl(ctx)
 	}
 	sw.initialized = false
+	sw.sequence.Store(0)
+	sw.buildDuration.Store(0)
 	sw.service = nil
 	sw.cl = nil
 	sw.dependency = nil
+	if len(sw.closers) > 0 {
+		err = errors.Join(err, sw.closeClosers(ctx))
+	}
 	return err
 }
 
+func (sw *serviceWrapper) closeFactory(ctx context.Context) error {
+	err := sw.closeClosers(ctx)
+	sw.scopes = nil
+	sw.sequence.Store(0)
+	sw.buildDuration.Store(0)
+	sw.dependency = nil
+	return err
+}
+
+// closeClosers calls the closers in the reverse order, and removes them.
+//
+// They are the closers of a factory service, or the orphan closers of the failed builds.
+func (sw *serviceWrapper) closeClosers(ctx context.Context) error {
+	var errs []error
+	for _, cl := range slices.Backward(sw.closers) {
+		err := cl(ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	sw.closers = nil
+	return errors.Join(errs...)
+}
+
+type closingContextKey struct{}
+
+// setClosingToContext marks the context as used by a [Close] function, in order to detect reentrant close calls.
+func setClosingToContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, closingContextKey{}, true)
+}
+
+func isClosingContext(ctx context.Context) bool {
+	v, _ := ctx.Value(closingContextKey{}).(bool)
+	return v
+}
+
 type serviceWrapperMap struct {
-	mu sync.Mutex
-	m  map[Key]*serviceWrapper
+	mu    sync.RWMutex
+	m     map[Key]*serviceWrapper
+	order []Key // Insertion order.
 }
 
 func (m *serviceWrapperMap) set(key Key, sw *serviceWrapper) error {
@@ -112,13 +409,81 @@ func (m *serviceWrapperMap) set(key Key, sw *serviceWrapper) error {
 		return ErrAlreadySet
 	}
 	m.m[key] = sw
+	m.order = append(m.order, key)
 	return nil
 }
 
-func (m *serviceWrapperMap) get(key Key) (*serviceWrapper, error) {
+// setAll sets all the [serviceWrapper]s atomically.
+//
+// If a key is already set, nothing is set, and it returns the index of the [serviceWrapper] and [ErrAlreadySet].
+func (m *serviceWrapperMap) setAll(sws []*serviceWrapper) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, sw := range sws {
+		_, ok := m.m[sw.key]
+		if ok {
+			return i, ErrAlreadySet
+		}
+	}
+	if m.m == nil {
+		m.m = make(map[Key]*serviceWrapper, len(sws))
+	}
+	for _, sw := range sws {
+		m.m[sw.key] = sw
+		m.order = append(m.order, sw.key)
+	}
+	return 0, nil
+}
+
+// setIfAbsent sets the [serviceWrapper] if the key is not set, and returns true if it was set.
+func (m *serviceWrapperMap) setIfAbsent(key Key, sw *serviceWrapper) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.m == nil {
+		m.m = make(map[Key]*serviceWrapper)
+	}
+	_, ok := m.m[key]
+	if ok {
+		return false
+	}
+	m.m[key] = sw
+	m.order = append(m.order, key)
+	return true
+}
+
+// swap replaces the [serviceWrapper] atomically, and returns the previous one.
+func (m *serviceWrapperMap) swap(key Key, sw *serviceWrapper) (*serviceWrapper, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, ok := m.m[key]
+	if !ok {
+		return nil, ErrNotSet
+	}
+	m.m[key] = sw
+	old.replaced.Store(true)
+	return old, nil
+}
+
+// update replaces the [serviceWrapper] with the one returned by f, atomically.
+func (m *serviceWrapperMap) update(key Key, f func(sw *serviceWrapper) (*serviceWrapper, error)) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	sw, ok := m.m[key]
+	if !ok {
+		return ErrNotSet
+	}
+	sw, err := f(sw)
+	if err != nil {
+		return err
+	}
+	m.m[key] = sw
+	return nil
+}
+
+func (m *serviceWrapperMap) get(key Key) (*serviceWrapper, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sw, ok := m.m[key]
 	if !ok {
 		return nil, ErrNotSet
 	}
@@ -126,19 +491,57 @@ func (m *serviceWrapperMap) get(key Key) (*serviceWrapper, error) {
 }
 
 func (m *serviceWrapperMap) all(f func(key Key, sw *serviceWrapper)) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	for key, sw := range m.m {
 		f(key, sw)
 	}
 }
 
-func (m *serviceWrapperMap) getValues() []*serviceWrapper {
+// allOrdered is like all, but it calls f in the insertion order.
+func (m *serviceWrapperMap) allOrdered(f func(key Key, sw *serviceWrapper)) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, key := range m.order {
+		f(key, m.m[key])
+	}
+}
+
+func (m *serviceWrapperMap) len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.m)
+}
+
+func (m *serviceWrapperMap) getKeys() []Key {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]Key, 0, len(m.m))
+	for key := range m.m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// reset removes all the [serviceWrapper]s, and returns them.
+func (m *serviceWrapperMap) reset() []*serviceWrapper {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	sws := make([]*serviceWrapper, 0, len(m.m))
 	for _, sw := range m.m {
 		sws = append(sws, sw)
 	}
+	m.m = nil
+	m.order = nil
+	return sws
+}
+
+func (m *serviceWrapperMap) getValues() []*serviceWrapper {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sws := make([]*serviceWrapper, 0, len(m.m))
+	for _, sw := range m.m {
+		sws = append(sws, sw)
+	}
 	return sws
 }
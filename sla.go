@@ -0,0 +1,36 @@
+package di
+
+import (
+	"time"
+)
+
+// WithBuildSLA returns a [ServiceOption] that sets the expected maximum build duration of a service.
+//
+// The SLA is advisory: if the [Builder] takes longer, the service is still returned, and the [SLOBreachHandler] of the [Container] is called.
+func WithBuildSLA(d time.Duration) ServiceOption {
+	return func(cfg *serviceConfig) {
+		cfg.buildSLA = d
+	}
+}
+
+// SLOBreachHandler is called when a service build is slower than its SLA.
+//
+// See [WithBuildSLA].
+type SLOBreachHandler func(key Key, actual, sla time.Duration)
+
+// OnSLOBreach sets the [SLOBreachHandler] of the [Container].
+//
+// It is called synchronously, after the build of the service.
+func (c *Container) OnSLOBreach(h SLOBreachHandler) {
+	c.onSLOBreach.Store(&h)
+}
+
+func (c *Container) checkBuildSLA(key Key, actual, sla time.Duration) {
+	if sla <= 0 || actual <= sla {
+		return
+	}
+	h := c.onSLOBreach.Load()
+	if h != nil && *h != nil {
+		(*h)(key, actual, sla)
+	}
+}
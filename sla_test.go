@@ -0,0 +1,35 @@
+package di
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithBuildSLA(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	var breachKey Key
+	var breachSLA time.Duration
+	ctn.OnSLOBreach(func(key Key, actual, sla time.Duration) {
+		breachKey = key
+		breachSLA = sla
+		assert.Greater(t, actual, sla)
+	})
+	MustSet(ctn, "slow", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		time.Sleep(10 * time.Millisecond)
+		return "slow", nil, nil
+	}, WithBuildSLA(1*time.Millisecond))
+	MustSet(ctn, "fast", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "fast", nil, nil
+	}, WithBuildSLA(1*time.Hour))
+	s := MustGet[string](ctx, ctn, "fast")
+	assert.Equal(t, s, "fast")
+	assert.Zero(t, breachKey)
+	s = MustGet[string](ctx, ctn, "slow")
+	assert.Equal(t, s, "slow")
+	assert.Equal(t, breachKey, newKey[string]("slow"))
+	assert.Equal(t, breachSLA, 1*time.Millisecond)
+}
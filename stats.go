@@ -0,0 +1,102 @@
+package di
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// BuildStats returns the [BuildStat] of all the services set to the [Container], sorted by [Key.String].
+//
+// It doesn't initialize the services.
+func (c *Container) BuildStats() []BuildStat {
+	var bss []BuildStat
+	c.all(func(key Key, sw *serviceWrapper) {
+		var d time.Duration
+		if sw.sequence.Load() != 0 {
+			d = time.Duration(sw.buildDuration.Load())
+		}
+		bss = append(bss, BuildStat{
+			Key:         key,
+			Duration:    d,
+			Initialized: sw.isInitialized(),
+		})
+	})
+	slices.SortFunc(bss, func(a, b BuildStat) int {
+		return cmp.Compare(a.Key.String(), b.Key.String())
+	})
+	return bss
+}
+
+// BuildStat contains the build statistics of a service.
+//
+// Duration is 0 if the service is not built.
+// For a factory service, it is the duration of the last build, and Initialized is always false, as in [IsInitialized].
+type BuildStat struct {
+	Key         Key
+	Duration    time.Duration
+	Initialized bool
+}
+
+// Stats returns the [DependencyStats] of the [Dependency] tree.
+//
+// The dependencies shared by several services are counted once.
+func (d *Dependency) Stats() DependencyStats {
+	st := DependencyStats{
+		FanIn: make(map[Key]int),
+	}
+	walkDependencyGraph([]*Dependency{d}, func(d *Dependency) {
+		st.Nodes++
+		if len(d.Dependencies) == 0 {
+			st.Leaves++
+		}
+		seen := make(map[Key]bool, len(d.Dependencies))
+		for _, dd := range d.Dependencies {
+			k := dd.exportedKey()
+			if !seen[k] {
+				seen[k] = true
+				st.FanIn[k]++
+			}
+		}
+	})
+	st.MaxDepth = d.maxDepth(make(map[Key]int))
+	maxFanIn := 0
+	for k, n := range st.FanIn {
+		if n > maxFanIn || (n == maxFanIn && k.String() < st.MostDependedUpon.String()) {
+			maxFanIn = n
+			st.MostDependedUpon = k
+		}
+	}
+	return st
+}
+
+// maxDepth returns the number of edges of the longest path from the [Dependency].
+// The depths are memoized by [Key], so the shared dependencies are only walked once.
+func (d *Dependency) maxDepth(memo map[Key]int) int {
+	k := d.exportedKey()
+	depth, ok := memo[k]
+	if ok {
+		return depth
+	}
+	for _, dd := range d.Dependencies {
+		depth = max(depth, dd.maxDepth(memo)+1)
+	}
+	memo[k] = depth
+	return depth
+}
+
+// DependencyStats contains statistics about a [Dependency] tree.
+type DependencyStats struct {
+	// Nodes is the number of unique services.
+	Nodes int
+	// MaxDepth is the number of edges of the longest path from the root.
+	MaxDepth int
+	// Leaves is the number of unique services without dependencies.
+	Leaves int
+	// MostDependedUpon is the service with the highest fan-in.
+	// It is the zero value if there is no dependency.
+	MostDependedUpon Key
+	// FanIn is the number of unique services that depend directly on a service.
+	// The root is not included.
+	FanIn map[Key]int
+}
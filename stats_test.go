@@ -0,0 +1,74 @@
+package di
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func TestContainerBuildStats(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		time.Sleep(1 * time.Millisecond)
+		return "a", nil, nil
+	})
+	MustSetValue(ctn, "b", "b")
+	MustGet[string](ctx, ctn, "a")
+	bss := ctn.BuildStats()
+	assert.SliceLen(t, bss, 2)
+	assert.Equal(t, bss[0].Key, newKey[string]("a"))
+	assert.True(t, bss[0].Initialized)
+	assert.GreaterOrEqual(t, bss[0].Duration, 1*time.Millisecond)
+	assert.Equal(t, bss[1], BuildStat{Key: newKey[string]("b")})
+	err := ctn.Close(ctx)
+	assert.NoError(t, err)
+	bss = ctn.BuildStats()
+	assert.Equal(t, bss[0], BuildStat{Key: newKey[string]("a")})
+}
+
+func TestContainerBuildStatsFactory(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetFactory(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		time.Sleep(1 * time.Millisecond)
+		return "", nil, nil
+	})
+	MustGet[string](ctx, ctn, "")
+	bss := ctn.BuildStats()
+	assert.SliceLen(t, bss, 1)
+	assert.False(t, bss[0].Initialized)
+	assert.GreaterOrEqual(t, bss[0].Duration, 1*time.Millisecond)
+	ok, err := IsInitialized[string](ctn, "")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, ctn.InitializedLen(), 0)
+}
+
+func TestDependencyStats(t *testing.T) {
+	deps := newTestDependencyGraph(t)
+	st := deps[1].Stats()
+	intKey := Key{Type: "*int"}
+	assert.DeepEqual(t, st, DependencyStats{
+		Nodes:            3,
+		MaxDepth:         2,
+		Leaves:           1,
+		MostDependedUpon: intKey,
+		FanIn: map[Key]int{
+			intKey:                      2,
+			{Type: "string", Name: "b"}: 1,
+		},
+	})
+}
+
+func TestDependencyStatsLeaf(t *testing.T) {
+	dep := &Dependency{Type: "string"}
+	st := dep.Stats()
+	assert.DeepEqual(t, st, DependencyStats{
+		Nodes:  1,
+		Leaves: 1,
+		FanIn:  map[Key]int{},
+	})
+}
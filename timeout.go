@@ -0,0 +1,26 @@
+package di
+
+import (
+	"time"
+)
+
+// SetWithTimeout calls [Set] with [WithBuildTimeout].
+func SetWithTimeout[S any](ctn *Container, name string, timeout time.Duration, b Builder[S], opts ...ServiceOption) error {
+	return Set(ctn, name, b, append([]ServiceOption{WithBuildTimeout(timeout)}, opts...)...)
+}
+
+// MustSetWithTimeout calls [SetWithTimeout] and panics if there is an error.
+func MustSetWithTimeout[S any](ctn *Container, name string, timeout time.Duration, b Builder[S], opts ...ServiceOption) {
+	MustSet(ctn, name, b, append([]ServiceOption{WithBuildTimeout(timeout)}, opts...)...)
+}
+
+// WithBuildTimeout returns a [ServiceOption] that sets the maximum build duration of a service.
+//
+// The [Builder] is called with a [context.Context] that is canceled after the timeout.
+// The timeout covers the whole build, including the build of the dependencies that are not yet initialized.
+// The [Builder] must return the context error, in order to fail with [context.DeadlineExceeded].
+func WithBuildTimeout(d time.Duration) ServiceOption {
+	return func(cfg *serviceConfig) {
+		cfg.buildTimeout = d
+	}
+}
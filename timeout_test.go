@@ -0,0 +1,52 @@
+package di
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func TestSetWithTimeout(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	err := SetWithTimeout(ctn, "", 1*time.Hour, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		return "test", nil, nil
+	})
+	assert.NoError(t, err)
+	s, err := Get[string](ctx, ctn, "")
+	assert.NoError(t, err)
+	assert.Equal(t, s, "test")
+}
+
+func TestSetWithTimeoutErrorDeadlineExceeded(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetWithTimeout(ctn, "a", 1*time.Millisecond, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		_, err := Get[string](ctx, ctn, "b")
+		return "", nil, err
+	})
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		<-ctx.Done()
+		return "", nil, ctx.Err()
+	})
+	_, err := Get[string](ctx, ctn, "a")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	var serviceErr *ServiceError
+	assert.ErrorAs(t, err, &serviceErr)
+	assert.Equal(t, serviceErr.Key, newKey[string]("a"))
+}
+
+func TestSetWithTimeoutErrorCycle(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetWithTimeout(ctn, "", 1*time.Hour, func(ctx context.Context, ctn *Container) (string, Close, error) {
+		_, err := Get[string](ctx, ctn, "")
+		return "", nil, err
+	})
+	_, err := Get[string](ctx, ctn, "")
+	assert.ErrorIs(t, err, ErrCycle)
+}
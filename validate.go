@@ -0,0 +1,129 @@
+package di
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Validate checks that all the services of the [Container] can be built.
+//
+// The services are built in a copy of the [Container], which is closed afterward.
+// So the [Container] is not modified: its services are not initialized.
+// The hooks (event handlers, [BuilderMiddleware]s, [SLOBreachHandler] and [BuildHook]) are not called for these builds.
+//
+// The declared dependencies (see [WithDependencies]) are checked first, without building the services.
+// If they are invalid, the services are not built.
+//
+// If a cycle is detected, it returns a [*CycleError].
+// Otherwise, the errors of all services are joined.
+func (c *Container) Validate(ctx context.Context) error {
+	cycles, errs := c.checkDeclaredDependencies()
+	if len(cycles) > 0 {
+		return &CycleError{Keys: cycles[0]}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	clone := c.clone(false)
+	for _, sw := range clone.getServiceWrappersInitialize() {
+		_, err := clone.get(ctx, sw.key)
+		if err == nil {
+			continue
+		}
+		var cycleErr *CycleError
+		if errors.As(err, &cycleErr) {
+			_ = clone.closeClone(ctx)
+			return cycleErr
+		}
+		errs = append(errs, err)
+	}
+	err := clone.closeClone(ctx)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// DetectCycles returns the dependency cycles of the [Container].
+//
+// As [Container.Validate], the services are built in a copy of the [Container], which is closed afterward.
+// Each cycle is a path of keys that starts and ends with the same [Key], as in [CycleError].
+// It starts with the smallest [Key] (by [Key.String]), and the cycles are sorted.
+// It returns nil if there is no cycle.
+//
+// All the elementary cycles of the declared dependencies (see [WithDependencies]) are found without building the services.
+// The other cycles are found by building the services, which stops at the first cycle, so it is best-effort: a cycle that shares services with another cycle can be missed.
+//
+// The other build errors, and the declared dependencies that are not set, are joined and returned.
+func (c *Container) DetectCycles(ctx context.Context) ([][]Key, error) {
+	cycles, errs := c.checkDeclaredDependencies()
+	seen := make(map[string]bool)
+	for _, cycle := range cycles {
+		seen[cycleID(cycle)] = true
+	}
+	clone := c.clone(false)
+	for _, sw := range clone.getServiceWrappersInitialize() {
+		_, err := clone.get(ctx, sw.key)
+		if err == nil {
+			continue
+		}
+		var cycleErr *CycleError
+		if !errors.As(err, &cycleErr) {
+			errs = append(errs, err)
+			continue
+		}
+		cycle := normalizeCycle(cycleErr.Keys)
+		id := cycleID(cycle)
+		if !seen[id] {
+			seen[id] = true
+			cycles = append(cycles, cycle)
+		}
+	}
+	err := clone.closeClone(ctx)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	slices.SortFunc(cycles, func(a, b []Key) int {
+		return cmp.Compare(cycleID(a), cycleID(b))
+	})
+	return cycles, errors.Join(errs...)
+}
+
+// normalizeCycle rotates the cycle, so it starts with the smallest [Key].
+func normalizeCycle(keys []Key) []Key {
+	keys = keys[:len(keys)-1] // The last key is the same as the first.
+	start := 0
+	for i, key := range keys {
+		if key.String() < keys[start].String() {
+			start = i
+		}
+	}
+	cycle := make([]Key, 0, len(keys)+1)
+	cycle = append(cycle, keys[start:]...)
+	cycle = append(cycle, keys[:start]...)
+	cycle = append(cycle, keys[start])
+	return cycle
+}
+
+func cycleID(keys []Key) string {
+	ss := make([]string, len(keys))
+	for i, key := range keys {
+		ss[i] = key.String()
+	}
+	return strings.Join(ss, " -> ")
+}
+
+// closeClone closes all the services of a clone and its parents, including the persistent services.
+func (c *Container) closeClone(ctx context.Context) error {
+	var errs []error
+	for ctn := c; ctn != nil; ctn = ctn.parent {
+		err := ctn.Reset(ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
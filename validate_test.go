@@ -0,0 +1,129 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestContainerValidate(t *testing.T) {
+	ctx := context.Background()
+	parent := new(Container)
+	MustSetValue(parent, "", 1)
+	ctn := parent.NewChild()
+	closed := false
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		MustGet[int](ctx, ctn, "")
+		return "", func(ctx context.Context) error {
+			closed = true
+			return nil
+		}, nil
+	})
+	err := ctn.Validate(ctx)
+	assert.NoError(t, err)
+	assert.True(t, closed)
+	assert.SliceEmpty(t, ctn.InitializationOrder())
+	assert.SliceEmpty(t, parent.InitializationOrder())
+}
+
+func TestContainerValidateHooks(t *testing.T) {
+	ctx := context.Background()
+	called := 0
+	ctn := NewContainer(WithBuildHook(func(ctx context.Context, key Key) (context.Context, func(err error)) {
+		called++
+		return ctx, func(err error) {}
+	}))
+	ctn.OnEvent(func(ev Event) {
+		called++
+	})
+	ctn.Use(func(key Key, next BuildFunc) BuildFunc {
+		called++
+		return next
+	})
+	MustSetValue(ctn, "", "")
+	err := ctn.Validate(ctx)
+	assert.NoError(t, err)
+	_, err = ctn.DetectCycles(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, called, 0)
+}
+
+func TestContainerValidateErrorCycle(t *testing.T) {
+	ctx := context.Background()
+	ctn := newTestContainerCycle()
+	MustSet(ctn, "0", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		_, err := Get[string](ctx, ctn, "a")
+		return "", nil, err
+	})
+	err := ctn.Validate(ctx)
+	assert.ErrorIs(t, err, ErrCycle)
+	var cycleErr *CycleError
+	assert.ErrorAs(t, err, &cycleErr)
+	assert.DeepEqual(t, cycleErr.Keys, []Key{newKey[string]("a"), newKey[string]("b"), newKey[string]("c"), newKey[string]("a")})
+	assert.ErrorEqual(t, err, "cycle: string(a) -> string(b) -> string(c) -> string(a)")
+	assert.SliceEmpty(t, ctn.InitializationOrder())
+}
+
+func TestContainerValidateErrorBuilder(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSet(ctn, "", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, errors.New("error")
+	})
+	err := ctn.Validate(ctx)
+	assert.ErrorEqual(t, err, "service string: error")
+}
+
+func TestContainerValidatePersistent(t *testing.T) {
+	ctx := context.Background()
+	parent := new(Container)
+	closed := 0
+	MustSetPersistent(parent, "a", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "a", func(ctx context.Context) error {
+			closed++
+			return nil
+		}, nil
+	})
+	child := parent.NewChild()
+	MustSet(child, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return MustGet[string](ctx, ctn, "a"), nil, nil
+	})
+	err := child.Validate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, closed, 1)
+}
+
+func TestContainerDetectCycles(t *testing.T) {
+	ctx := context.Background()
+	ctn := newTestContainerCycle()
+	MustSet(ctn, "d", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		_, err := Get[string](ctx, ctn, "d")
+		return "", nil, err
+	})
+	MustSet(ctn, "e", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		_, err := Get[string](ctx, ctn, "c")
+		return "", nil, err
+	})
+	MustSetValue(ctn, "f", "f")
+	cycles, err := ctn.DetectCycles(ctx)
+	assert.NoError(t, err)
+	a, b, c, d := newKey[string]("a"), newKey[string]("b"), newKey[string]("c"), newKey[string]("d")
+	assert.DeepEqual(t, cycles, [][]Key{{a, b, c, a}, {d, d}})
+	ok, err := IsInitialized[string](ctn, "f")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestContainerDetectCyclesNone(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	MustSetValue(ctn, "a", "a")
+	MustSet(ctn, "b", func(ctx context.Context, ctn *Container) (string, Close, error) {
+		return "", nil, errors.New("error")
+	})
+	cycles, err := ctn.DetectCycles(ctx)
+	assert.ErrorEqual(t, err, "service string(b): error")
+	assert.SliceEmpty(t, cycles)
+}
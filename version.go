@@ -0,0 +1,71 @@
+package di
+
+import (
+	"context"
+	"reflect"
+)
+
+// SetVersioned sets a versioned service to a [Container].
+//
+// The version is a part of the service [Key], so multiple versions of the same service can coexist.
+// Version 0 is the service that is not versioned, and is the same as [Set].
+func SetVersioned[S any](ctn *Container, name string, version int, b Builder[S], opts ...ServiceOption) error {
+	key := newVersionedKey[S](name, version)
+	typ := reflect.TypeFor[S]()
+	return ctn.set(key, typ, newBuilder(b), opts)
+}
+
+// MustSetVersioned calls [SetVersioned] and panics if there is an error.
+func MustSetVersioned[S any](ctn *Container, name string, version int, b Builder[S], opts ...ServiceOption) {
+	err := SetVersioned(ctn, name, version, b, opts...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GetVersioned returns a versioned service from a [Resolver].
+//
+// See [Get].
+func GetVersioned[S any](ctx context.Context, ctn Resolver, name string, version int) (S, error) {
+	key := newVersionedKey[S](name, version)
+	return get[S](ctx, ctn, key)
+}
+
+// MustGetVersioned calls [GetVersioned] and panics if there is an error.
+func MustGetVersioned[S any](ctx context.Context, ctn Resolver, name string, version int) S {
+	s, err := GetVersioned[S](ctx, ctn, name, version)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// GetLatestVersion returns the service with the highest version from a [Resolver].
+//
+// If no version of the service is set, it returns [ErrNotSet].
+func GetLatestVersion[S any](ctx context.Context, ctn Resolver, name string) (S, error) {
+	key := newKey[S](name)
+	found := false
+	ctn.container().allResolvable(func(k Key, sw *serviceWrapper) {
+		if k.Type == key.Type && k.Name == key.Name && (!found || k.Version > key.Version) {
+			key = k
+			found = true
+		}
+	})
+	return get[S](ctx, ctn, key)
+}
+
+// MustGetLatestVersion calls [GetLatestVersion] and panics if there is an error.
+func MustGetLatestVersion[S any](ctx context.Context, ctn Resolver, name string) S {
+	s, err := GetLatestVersion[S](ctx, ctn, name)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func newVersionedKey[S any](name string, version int) Key {
+	key := newKey[S](name)
+	key.Version = version
+	return key
+}
@@ -0,0 +1,61 @@
+package di
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestVersioned(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	for _, version := range []int{1, 3, 2} {
+		err := SetVersioned(ctn, "", version, func(ctx context.Context, ctn *Container) (int, Close, error) {
+			return version, nil, nil
+		})
+		assert.NoError(t, err)
+	}
+	s, err := GetVersioned[int](ctx, ctn, "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, s, 2)
+	s, err = GetLatestVersion[int](ctx, ctn, "")
+	assert.NoError(t, err)
+	assert.Equal(t, s, 3)
+	ss, err := GetAll[int](ctx, ctn)
+	assert.NoError(t, err)
+	assert.MapLen(t, ss, 0)
+}
+
+func TestSetVersionedErrorAlreadySet(t *testing.T) {
+	ctn := new(Container)
+	MustSetVersioned(ctn, "", 1, func(ctx context.Context, ctn *Container) (int, Close, error) {
+		return 0, nil, nil
+	})
+	assert.Panics(t, func() {
+		MustSetVersioned(ctn, "", 1, func(ctx context.Context, ctn *Container) (int, Close, error) {
+			return 0, nil, nil
+		})
+	})
+}
+
+func TestGetVersionedErrorNotSet(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	_, err := GetVersioned[int](ctx, ctn, "test", 1)
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.ErrorEqual(t, err, "service int(test)@v1: not set")
+	assert.Panics(t, func() {
+		MustGetVersioned[int](ctx, ctn, "test", 1)
+	})
+}
+
+func TestGetLatestVersionErrorNotSet(t *testing.T) {
+	ctx := context.Background()
+	ctn := new(Container)
+	_, err := GetLatestVersion[int](ctx, ctn, "")
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.Panics(t, func() {
+		MustGetLatestVersion[int](ctx, ctn, "")
+	})
+}